@@ -0,0 +1,88 @@
+package pongo2
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// CacheBackend stores the rendered fragments of the cache-tag. Implementations
+// must be safe for concurrent use since templates may be executed in parallel.
+type CacheBackend interface {
+	// Get returns the fragment stored for key; the bool is false if there's
+	// none (or it has expired).
+	Get(key string) (string, bool)
+
+	// Set stores the fragment val for key for the duration of ttl.
+	Set(key string, val string, ttl time.Duration)
+}
+
+// DefaultCacheSize is the number of fragments the in-memory cache backend of
+// a TemplateSet created by NewSet holds.
+const DefaultCacheSize = 1000
+
+type memoryCacheEntry struct {
+	key     string
+	val     string
+	expires time.Time
+}
+
+type memoryCache struct {
+	size int
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List // front = most recently used
+}
+
+// NewMemoryCache returns an in-memory CacheBackend holding at most size fragments.
+// If it's full, the least recently used fragment gets evicted.
+func NewMemoryCache(size int) CacheBackend {
+	if size <= 0 {
+		size = DefaultCacheSize
+	}
+	return &memoryCache{
+		size:    size,
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+func (c *memoryCache) Get(key string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, has := c.entries[key]
+	if !has {
+		return "", false
+	}
+	entry := elem.Value.(*memoryCacheEntry)
+	if !time.Now().Before(entry.expires) {
+		c.lru.Remove(elem)
+		delete(c.entries, key)
+		return "", false
+	}
+	c.lru.MoveToFront(elem)
+	return entry.val, true
+}
+
+func (c *memoryCache) Set(key string, val string, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expires := time.Now().Add(ttl)
+	if elem, has := c.entries[key]; has {
+		entry := elem.Value.(*memoryCacheEntry)
+		entry.val = val
+		entry.expires = expires
+		c.lru.MoveToFront(elem)
+		return
+	}
+
+	c.entries[key] = c.lru.PushFront(&memoryCacheEntry{key: key, val: val, expires: expires})
+	for c.lru.Len() > c.size {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*memoryCacheEntry).key)
+	}
+}
@@ -1,12 +1,18 @@
 package pongo2
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"math"
 	"regexp"
+	"sync/atomic"
 )
 
-var reIdentifiers = regexp.MustCompile("^[a-zA-Z0-9_]+$")
+var (
+	reIdentifiers  = regexp.MustCompile("^[a-zA-Z0-9_]+$")
+	reVariableName = regexp.MustCompile("^[a-zA-Z_][a-zA-Z0-9_]*$")
+)
 
 var autoescape = true
 
@@ -48,6 +54,33 @@ func (c Context) Update(other Context) Context {
 	return c
 }
 
+// Merge returns a new context containing the key/value-pairs of this and
+// another context; the values of the other context take precedence. Neither
+// of both contexts is modified.
+func (c Context) Merge(other Context) Context {
+	merged := make(Context, len(c)+len(other))
+	merged.Update(c)
+	merged.Update(other)
+	return merged
+}
+
+// Validate checks whether all keys of this context are identifiers which can
+// be used as variable names within a template: a letter or underscore
+// followed by letters, digits or underscores, not being a keyword.
+func (c Context) Validate() error {
+	for k := range c {
+		if !reVariableName.MatchString(k) {
+			return fmt.Errorf("context-key '%s' is not a valid variable name", k)
+		}
+		for _, kw := range TokenKeywords {
+			if k == kw {
+				return fmt.Errorf("context-key '%s' is a keyword and can't be used as a variable name", k)
+			}
+		}
+	}
+	return nil
+}
+
 // ExecutionContext contains all data important for the current rendering state.
 //
 // If you're writing a custom tag, your tag's Execute()-function will
@@ -64,8 +97,10 @@ func (c Context) Update(other Context) Context {
 // To create your own execution context within tags, use the
 // NewChildExecutionContext(parent) function.
 type ExecutionContext struct {
-	template   *Template
-	macroDepth int
+	template     *Template
+	macroDepth   int
+	cancellation *executionCancellation
+	tagStates    map[tagStateKey]any
 
 	Autoescape bool
 	Public     Context
@@ -88,7 +123,9 @@ func newExecutionContext(tpl *Template, ctx Context) *ExecutionContext {
 
 		Public:     ctx,
 		Private:    privateCtx,
+		Shared:     make(Context),
 		Autoescape: autoescape,
+		tagStates:  make(map[tagStateKey]any),
 	}
 }
 
@@ -101,6 +138,8 @@ func NewChildExecutionContext(parent *ExecutionContext) *ExecutionContext {
 		Autoescape: parent.Autoescape,
 	}
 	newctx.Shared = parent.Shared
+	newctx.cancellation = parent.cancellation
+	newctx.tagStates = parent.tagStates
 
 	// Copy all existing private items
 	newctx.Private.Update(parent.Private)
@@ -108,6 +147,30 @@ func NewChildExecutionContext(parent *ExecutionContext) *ExecutionContext {
 	return newctx
 }
 
+// tagStateKey identifies the state of a tag node within the innermost
+// loop's current execution (or within the execution if there's no loop).
+type tagStateKey struct {
+	node INode
+	loop *tagForLoopInformation
+}
+
+func (ctx *ExecutionContext) tagStateKey(node INode) tagStateKey {
+	loop, _ := ctx.Private["forloop"].(*tagForLoopInformation)
+	return tagStateKey{node: node, loop: loop}
+}
+
+// tagState returns the state a tag node (like cycle or ifchanged) stored for
+// the current execution using setTagState. The state is scoped to the
+// innermost loop, so it's reset whenever the enclosing loop starts again.
+func (ctx *ExecutionContext) tagState(node INode) (any, bool) {
+	state, has := ctx.tagStates[ctx.tagStateKey(node)]
+	return state, has
+}
+
+func (ctx *ExecutionContext) setTagState(node INode, state any) {
+	ctx.tagStates[ctx.tagStateKey(node)] = state
+}
+
 func (ctx *ExecutionContext) Error(msg string, token *Token) *Error {
 	return ctx.OrigError(errors.New(msg), token)
 }
@@ -133,6 +196,65 @@ func (ctx *ExecutionContext) OrigError(err error, token *Token) *Error {
 	}
 }
 
+// executionError returns err as *Error; other errors (like the ones of the
+// writer when executing unbuffered) are attributed to the current execution.
+func (ctx *ExecutionContext) executionError(err error) *Error {
+	if e, ok := err.(*Error); ok {
+		return e
+	}
+	return ctx.OrigError(err, nil)
+}
+
 func (ctx *ExecutionContext) Logf(format string, args ...any) {
 	ctx.template.set.logf(format, args...)
 }
+
+// Accessed atomically (see SetCancellationCheckInterval)
+var cancellationCheckInterval int32 = 64
+
+// SetCancellationCheckInterval sets how often an execution started by
+// Template.ExecuteCtx checks whether its context.Context is done: once every
+// n executed nodes or loop iterations. Defaults to 64; values below 1 make
+// it check every time. It's safe to call while templates are executed; a
+// running execution keeps the interval it has been started with.
+func SetCancellationCheckInterval(n int) {
+	if n < 1 {
+		n = 1
+	}
+	if n > math.MaxInt32 {
+		n = math.MaxInt32
+	}
+	atomic.StoreInt32(&cancellationCheckInterval, int32(n))
+}
+
+// executionCancellation keeps track of the context.Context of an execution;
+// it's shared by an ExecutionContext and all of its children.
+type executionCancellation struct {
+	goctx    context.Context
+	interval int
+	checks   int
+}
+
+func newExecutionCancellation(goctx context.Context) *executionCancellation {
+	return &executionCancellation{
+		goctx:    goctx,
+		interval: int(atomic.LoadInt32(&cancellationCheckInterval)),
+	}
+}
+
+// checkCancellation returns an error if the execution's context.Context is
+// done (checked every interval calls).
+func (ctx *ExecutionContext) checkCancellation() *Error {
+	c := ctx.cancellation
+	if c == nil {
+		return nil
+	}
+	c.checks++
+	if c.checks%c.interval != 0 {
+		return nil
+	}
+	if err := c.goctx.Err(); err != nil {
+		return ctx.OrigError(err, nil)
+	}
+	return nil
+}
@@ -2,8 +2,10 @@ package pongo2
 
 import (
 	"bufio"
+	"encoding/json"
 	"fmt"
 	"os"
+	"strings"
 )
 
 // The Error type is being used to address an error during lexing, parsing or
@@ -33,6 +35,9 @@ func (e *Error) updateFromTokenIfNeeded(template *Template, t *Token) *Error {
 			e.Line = t.Line
 			e.Column = t.Col
 		}
+		if e.Filename == "" {
+			e.Filename = t.Filename
+		}
 	}
 
 	return e
@@ -54,10 +59,77 @@ func (e *Error) Error() string {
 		}
 	}
 	s += "] "
-	s += e.OrigError.Error()
+	if e.OrigError != nil {
+		s += e.OrigError.Error()
+	}
 	return s
 }
 
+// Fields returns the error's details for structured logging: sender,
+// message (the underlying error's message), file, line, col and template
+// (the name of the template the error occurred in).
+func (e *Error) Fields() map[string]any {
+	message := ""
+	if e.OrigError != nil {
+		message = e.OrigError.Error()
+	}
+	template := ""
+	if e.Template != nil {
+		template = e.Template.name
+	}
+	return map[string]any{
+		"sender":   e.Sender,
+		"message":  message,
+		"file":     e.Filename,
+		"line":     e.Line,
+		"col":      e.Column,
+		"template": template,
+	}
+}
+
+// MarshalJSON encodes the error's Fields as JSON object.
+func (e *Error) MarshalJSON() ([]byte, error) {
+	return json.Marshal(e.Fields())
+}
+
+// Unwrap returns the underlying error.
+func (e *Error) Unwrap() error {
+	return e.OrigError
+}
+
+// ErrorList contains all errors found while parsing a template if the
+// template set collects all parse errors (see TemplateSet.CollectParseErrors).
+type ErrorList []*Error
+
+// Returns the errors' strings (one error per line).
+func (el ErrorList) Error() string {
+	msgs := make([]string, 0, len(el))
+	for _, e := range el {
+		msgs = append(msgs, e.Error())
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// asError returns err (an error returned when loading a template) as *Error.
+// An ErrorList is wrapped within an *Error.
+func asError(err error) *Error {
+	switch e := err.(type) {
+	case *Error:
+		return e
+	case ErrorList:
+		return &Error{
+			Filename:  e[0].Filename,
+			Sender:    "parser",
+			OrigError: e,
+		}
+	default:
+		return &Error{
+			Sender:    "parser",
+			OrigError: err,
+		}
+	}
+}
+
 // RawLine returns the affected line from the original template, if available.
 func (e *Error) RawLine() (line string, available bool, outErr error) {
 	if e.Line <= 0 || e.Filename == "<string>" {
@@ -2,15 +2,73 @@ package pongo2
 
 import (
 	"fmt"
+	"sort"
 	"sync"
+	"sync/atomic"
 )
 
 // FilterFunction is the type filter functions must fulfil
+//
+// bind is the map the filter is bound to: the public context of the current
+// rendering process (or the map given to ApplyFilter). Filters may write into
+// it (e. g. to signal whether their input was truncated); these changes are
+// visible to the remaining template (and to the caller of ApplyFilter).
 type FilterFunction func(in *Value, param *Value, bind map[string]any) (out *Value, err *Error)
 
-// var filters map[string]FilterFunction
+// ContextFilterFunction is the type context-aware filter functions must fulfil.
+// Contrary to a FilterFunction it has access to the whole ExecutionContext
+// of the current rendering process.
+type ContextFilterFunction func(ctx *ExecutionContext, in *Value, param *Value) (out *Value, err *Error)
+
+// var filters map[string]FilterFunction (or ContextFilterFunction)
 var filters *sync.Map
 
+// 1 if panicking filters are recovered from (see SetFilterPanicRecovery)
+var recoverFilterPanics int32
+
+// SetFilterPanicRecovery enables (or disables) the recovery from panicking
+// filters globally. If enabled, a panic within a filter is turned into an
+// *Error instead of crashing the whole rendering process. Defaults to false.
+// It's safe to call while templates are executed. See also
+// TemplateSet.RecoverFilterPanics to enable it for a single set.
+func SetFilterPanicRecovery(newValue bool) {
+	if newValue {
+		atomic.StoreInt32(&recoverFilterPanics, 1)
+	} else {
+		atomic.StoreInt32(&recoverFilterPanics, 0)
+	}
+}
+
+// callFilter calls a filter function, recovering from a panic if requested
+// (by recoverPanics or SetFilterPanicRecovery). Errors returned without a
+// sender are attributed to the filter; returned errors are always copied.
+func callFilter(name string, recoverPanics bool, fn func() (*Value, *Error)) (out *Value, err *Error) {
+	defer func() {
+		if err != nil {
+			// The filter might return the same *Error for every call (e.g. a
+			// package-level variable), so a copy gets the sender and position.
+			errCopy := *err
+			if errCopy.Sender == "" {
+				errCopy.Sender = "filter:" + name
+			}
+			err = &errCopy
+		}
+	}()
+	if recoverPanics || atomic.LoadInt32(&recoverFilterPanics) == 1 {
+		defer func() {
+			if r := recover(); r != nil {
+				out = nil
+				if rerr, ok := r.(error); ok {
+					err = &Error{Sender: "filter:" + name, OrigError: fmt.Errorf("filter panicked: %w", rerr)}
+				} else {
+					err = &Error{Sender: "filter:" + name, OrigError: fmt.Errorf("filter panicked: %v", r)}
+				}
+			}
+		}()
+	}
+	return fn()
+}
+
 func init() {
 	filters = new(sync.Map)
 }
@@ -21,6 +79,22 @@ func FilterExists(name string) bool {
 	return existing
 }
 
+// ListFilters returns the names of all registered filters in sorted order.
+func ListFilters() []string {
+	var names []string
+	filters.Range(func(key, value any) bool {
+		names = append(names, key.(string))
+		return true
+	})
+	sort.Strings(names)
+	return names
+}
+
+// FilterNames is an alias of ListFilters.
+func FilterNames() []string {
+	return ListFilters()
+}
+
 // RegisterFilter registers a new filter. If there's already a filter with the same. You usually
 // want to call this function in the filter's init() function:
 //
@@ -34,6 +108,45 @@ func RegisterFilter(name string, fn FilterFunction) error {
 	return nil
 }
 
+// RegisterFilters registers several filters at once. All names are checked
+// before any filter gets registered, so if one of them is already registered,
+// an error naming it is returned and none of the given filters is registered.
+func RegisterFilters(fns map[string]FilterFunction) error {
+	names := make([]string, 0, len(fns))
+	for name := range fns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if FilterExists(name) {
+			return fmt.Errorf("filter with name '%s' is already registered", name)
+		}
+	}
+	for _, name := range names {
+		filters.Store(name, fns[name])
+	}
+	return nil
+}
+
+// MustRegisterFilters behaves like RegisterFilters, but panics on an error.
+func MustRegisterFilters(fns map[string]FilterFunction) {
+	if err := RegisterFilters(fns); err != nil {
+		panic(err)
+	}
+}
+
+// RegisterContextFilter registers a new context-aware filter. It's being used
+// within templates exactly like a filter registered by RegisterFilter.
+func RegisterContextFilter(name string, fn ContextFilterFunction) error {
+	if FilterExists(name) {
+		return fmt.Errorf("filter with name '%s' is already registered", name)
+	}
+
+	filters.Store(name, fn)
+	return nil
+}
+
 // ReplaceFilter replaces an already registered filter with a new implementation. Use this
 // function with caution since it allows you to change existing filter behaviour.
 func ReplaceFilter(name string, fn FilterFunction) error {
@@ -44,6 +157,15 @@ func ReplaceFilter(name string, fn FilterFunction) error {
 	return nil
 }
 
+// UnregisterFilter removes a registered filter. Templates parsed afterwards
+// can't use the filter anymore; already parsed templates keep their reference.
+func UnregisterFilter(name string) error {
+	if _, loaded := filters.LoadAndDelete(name); !loaded {
+		return fmt.Errorf("filter with name '%s' does not exist (therefore cannot be unregistered)", name)
+	}
+	return nil
+}
+
 func OverrideFilter(name string, fn FilterFunction) error {
 	filters.Delete(name)
 	filters.Store(name, fn)
@@ -51,16 +173,17 @@ func OverrideFilter(name string, fn FilterFunction) error {
 }
 
 // MustApplyFilter behaves like ApplyFilter, but panics on an error.
-//func MustApplyFilter(name string, value *Value, param *Value) *Value {
-//	val, err := ApplyFilter(name, value, param)
-//	if err != nil {
-//		panic(err)
-//	}
-//	return val
-//}
+func MustApplyFilter(name string, value *Value, param *Value, bind map[string]any) *Value {
+	val, err := ApplyFilter(name, value, param, bind)
+	if err != nil {
+		panic(err)
+	}
+	return val
+}
 
 // ApplyFilter applies a filter to a given value using the given parameters.
-// Returns a *pongo2.Value or an error.
+// Returns a *pongo2.Value or an error. The filter receives bind as is, so the
+// caller can observe any changes the filter makes to it.
 func ApplyFilter(name string, value *Value, param *Value, bind map[string]any) (*Value, *Error) {
 	storedValue, existing := filters.Load(name)
 	if !existing {
@@ -75,40 +198,121 @@ func ApplyFilter(name string, value *Value, param *Value, bind map[string]any) (
 		param = AsValue(nil)
 	}
 
-	fn, _ := storedValue.(FilterFunction)
-	return fn(value, param, bind)
-}
+	// Filters may write into bind, so make sure it's never nil
+	if bind == nil {
+		bind = make(map[string]any)
+	}
 
-type filterCall struct {
-	token *Token
+	fn, ok := storedValue.(FilterFunction)
+	if !ok {
+		return nil, &Error{
+			Sender:    "applyfilter",
+			OrigError: fmt.Errorf("filter with name '%s' requires an execution context", name),
+		}
+	}
+	return callFilter(name, false, func() (*Value, *Error) {
+		return fn(value, param, bind)
+	})
+}
 
-	name      string
-	parameter IEvaluator
+// filterArguments is the underlying type of the parameter a filter receives
+// when it has been called with more than one argument.
+type filterArguments []*Value
 
-	filterFunc FilterFunction
+// FilterArguments returns the arguments a filter has been called with. If the
+// filter was called with several comma-separated arguments like
+//
+//	{{ value|myfilter:"old","new" }}
+//
+// all of them are returned in order. A single argument is returned as a
+// one-element slice; no argument at all (a nil param) returns an empty slice.
+func FilterArguments(param *Value) []*Value {
+	if param == nil || param.IsNil() {
+		return nil
+	}
+	if args, ok := param.Interface().(filterArguments); ok {
+		return args
+	}
+	return []*Value{param}
 }
 
-func (fc *filterCall) Execute(v *Value, ctx *ExecutionContext) (*Value, *Error) {
-	var param *Value
-	var err *Error
+// evaluateFilterArguments evaluates the given filter arguments and packs them
+// into the single parameter Value a FilterFunction receives.
+func evaluateFilterArguments(ctx *ExecutionContext, parameters []IEvaluator) (*Value, *Error) {
+	switch len(parameters) {
+	case 0:
+		return AsValue(nil), nil
+	case 1:
+		return parameters[0].Evaluate(ctx)
+	}
 
-	if fc.parameter != nil {
-		param, err = fc.parameter.Evaluate(ctx)
+	args := make(filterArguments, 0, len(parameters))
+	for _, parameter := range parameters {
+		arg, err := parameter.Evaluate(ctx)
 		if err != nil {
 			return nil, err
 		}
-	} else {
-		param = AsValue(nil)
+		args = append(args, arg)
 	}
+	return AsValue(args), nil
+}
 
-	filteredValue, err := fc.filterFunc(v, param, ctx.Public)
+type filterCall struct {
+	token *Token
+
+	name       string
+	parameters []IEvaluator
+
+	filterFunc        FilterFunction
+	contextFilterFunc ContextFilterFunction
+}
+
+func (fc *filterCall) Execute(v *Value, ctx *ExecutionContext) (*Value, *Error) {
+	param, err := evaluateFilterArguments(ctx, fc.parameters)
+	if err != nil {
+		return nil, err
+	}
+
+	filteredValue, err := executeFilter(ctx, fc.name, fc.filterFunc, fc.contextFilterFunc, v, param)
 	if err != nil {
 		return nil, err.updateFromTokenIfNeeded(ctx.template, fc.token)
 	}
 	return filteredValue, nil
 }
 
-// Filter = IDENT | IDENT ":" FilterArg | IDENT "|" Filter
+// executeFilter calls a filter within the given execution context: a context
+// filter (if contextFilterFunc is set) gets the context itself, a regular one
+// is bound to its public context.
+func executeFilter(ctx *ExecutionContext, name string, filterFunc FilterFunction, contextFilterFunc ContextFilterFunction, in *Value, param *Value) (*Value, *Error) {
+	recoverPanics := ctx.template != nil && ctx.template.set.RecoverFilterPanics
+	return callFilter(name, recoverPanics, func() (*Value, *Error) {
+		if contextFilterFunc != nil {
+			return contextFilterFunc(ctx, in, param)
+		}
+		return filterFunc(in, param, ctx.Public)
+	})
+}
+
+// FilterArgs = FilterArg { "," FilterArg }
+//
+// Within a function call's argument list or an array literal a comma
+// separates the list's items, hence only one filter argument is allowed there.
+func (p *Parser) parseFilterArguments() ([]IEvaluator, *Error) {
+	var parameters []IEvaluator
+	for {
+		v, err := p.parseVariableOrLiteral()
+		if err != nil {
+			return nil, err
+		}
+		parameters = append(parameters, v)
+
+		if p.argumentListDepth > 0 || p.Match(TokenSymbol, ",") == nil {
+			return parameters, nil
+		}
+	}
+}
+
+// Filter = IDENT | IDENT ":" FilterArgs | IDENT "|" Filter
 func (p *Parser) parseFilter() (*filterCall, *Error) {
 	identToken := p.MatchType(TokenIdentifier)
 
@@ -127,9 +331,12 @@ func (p *Parser) parseFilter() (*filterCall, *Error) {
 	if !exists {
 		return nil, p.Error(fmt.Sprintf("Filter '%s' does not exist.", identToken.Val), identToken)
 	}
-	filterFn, _ := storedFunc.(FilterFunction)
-
-	filter.filterFunc = filterFn
+	switch fn := storedFunc.(type) {
+	case FilterFunction:
+		filter.filterFunc = fn
+	case ContextFilterFunction:
+		filter.contextFilterFunc = fn
+	}
 
 	// Check for filter-argument (2 tokens needed: ':' ARG)
 	if p.Match(TokenSymbol, ":") != nil {
@@ -137,12 +344,12 @@ func (p *Parser) parseFilter() (*filterCall, *Error) {
 			return nil, p.Error("Filter parameter required after ':'.", nil)
 		}
 
-		// Get filter argument expression
-		v, err := p.parseVariableOrLiteral()
+		// Get filter argument expressions
+		parameters, err := p.parseFilterArguments()
 		if err != nil {
 			return nil, err
 		}
-		filter.parameter = v
+		filter.parameters = parameters
 	}
 
 	return filter, nil
@@ -3,10 +3,7 @@ package pongo2
 /* Filters that are provided through github.com/flosch/pongo2-addons:
    ------------------------------------------------------------------
 
-   filesizeformat
    slugify
-   timesince
-   timeuntil
 
    Filters that won't be added:
    ----------------------------
@@ -18,24 +15,30 @@ package pongo2
    Reconsideration (not implemented yet):
    --------------------------------------
 
-   force_escape (reason: not yet needed since this is the behaviour of pongo2's escape filter)
-   safeseq (reason: same reason as `force_escape`)
+   safeseq (reason: not yet needed since items are being escaped on output anyway)
    unordered_list (python-specific; not sure whether needed or not)
-   dictsort (python-specific; maybe one could add a filter to sort a list of structs by a specific field name)
-   dictsortreversed (see dictsort)
 */
 
 import (
 	"bytes"
+	"container/list"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
+	"math/big"
 	"math/rand"
 	"net/url"
 	"reflect"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
+	"unicode"
+	"unicode/utf16"
 	"unicode/utf8"
 )
 
@@ -44,23 +47,37 @@ func init() {
 
 	RegisterFilter("escape", filterEscape)
 	RegisterFilter("e", filterEscape) // alias of `escape`
+	RegisterFilter("escape_once", filterEscapeOnce)
+	RegisterFilter("force_escape", filterForceEscape)
 	RegisterFilter("safe", filterSafe)
 	RegisterFilter("escapejs", filterEscapejs)
 
 	RegisterFilter("add", filterAdd)
 	RegisterFilter("addslashes", filterAddslashes)
+	RegisterContextFilter("apply", filterApply)
+	RegisterFilter("b64decode", filterB64decode)
+	RegisterFilter("b64encode", filterB64encode)
+	RegisterFilter("batch", filterBatch)
 	RegisterFilter("capfirst", filterCapfirst)
 	RegisterFilter("center", filterCenter)
 	RegisterFilter("cut", filterCut)
 	RegisterFilter("date", filterDate)
 	RegisterFilter("default", filterDefault)
 	RegisterFilter("default_if_none", filterDefaultIfNone)
+	RegisterFilter("dictsort", filterDictsort)
+	RegisterFilter("dictsortreversed", filterDictsortreversed)
 	RegisterFilter("divisibleby", filterDivisibleby)
+	RegisterFilter("duration", filterDuration)
+	RegisterFilter("filesizeformat", filterFilesizeformat)
 	RegisterFilter("first", filterFirst)
 	RegisterFilter("floatformat", filterFloatformat)
+	RegisterFilter("get", filterGet)
 	RegisterFilter("get_digit", filterGetdigit)
+	RegisterFilter("group_by", filterGroupBy)
 	RegisterFilter("iriencode", filterIriencode)
+	RegisterFilter("isempty", filterIsempty)
 	RegisterFilter("join", filterJoin)
+	RegisterFilter("json", filterJSON)
 	RegisterFilter("last", filterLast)
 	RegisterFilter("length", filterLength)
 	RegisterFilter("length_is", filterLengthis)
@@ -70,21 +87,34 @@ func init() {
 	RegisterFilter("ljust", filterLjust)
 	RegisterFilter("lower", filterLower)
 	RegisterFilter("make_list", filterMakelist)
+	RegisterFilter("nl2br", filterNl2br)
+	RegisterFilter("number_format", filterNumberFormat)
 	RegisterFilter("phone2numeric", filterPhone2numeric)
+	RegisterFilter("pluck", filterPluck)
 	RegisterFilter("pluralize", filterPluralize)
 	RegisterFilter("random", filterRandom)
+	RegisterFilter("range", filterRange)
+	RegisterFilter("regex_replace", filterRegexReplace)
 	RegisterFilter("removetags", filterRemovetags)
 	RegisterFilter("rjust", filterRjust)
+	RegisterFilter("shuffle", filterShuffle)
 	RegisterFilter("slice", filterSlice)
+	RegisterFilter("slugify_unicode", filterSlugifyUnicode)
+	RegisterFilter("sort", filterSort)
 	RegisterFilter("split", filterSplit)
 	RegisterFilter("stringformat", filterStringformat)
 	RegisterFilter("striptags", filterStriptags)
 	RegisterFilter("time", filterDate) // time uses filterDate (same golang-format)
+	RegisterFilter("timesince", filterTimesince)
+	RegisterFilter("timeuntil", filterTimeuntil)
 	RegisterFilter("title", filterTitle)
+	RegisterFilter("trim", filterTrim)
 	RegisterFilter("truncatechars", filterTruncatechars)
 	RegisterFilter("truncatechars_html", filterTruncatecharsHTML)
+	RegisterFilter("truncatehtml", filterTruncateHTML)
 	RegisterFilter("truncatewords", filterTruncatewords)
 	RegisterFilter("truncatewords_html", filterTruncatewordsHTML)
+	RegisterFilter("unique", filterUnique)
 	RegisterFilter("upper", filterUpper)
 	RegisterFilter("urlencode", filterUrlencode)
 	RegisterFilter("urlize", filterUrlize)
@@ -112,6 +142,12 @@ func filterTruncatecharsHelper(s string, newLen int) string {
 	return string(runes)
 }
 
+var filterTruncateHTMLVoidElements = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true,
+	"hr": true, "img": true, "input": true, "link": true, "meta": true,
+	"param": true, "source": true, "track": true, "wbr": true,
+}
+
 func filterTruncateHTMLHelper(value string, newOutput *bytes.Buffer, cond func() bool, fn func(c rune, s int, idx int) int, finalize func()) {
 	vLen := len(value)
 	var tagStack []string
@@ -174,6 +210,7 @@ func filterTruncateHTMLHelper(value string, newOutput *bytes.Buffer, cond func()
 					tag := ""
 
 					params := false
+					selfClosing := false
 					for idx < vLen {
 						c2, size2 := utf8.DecodeRuneInString(value[idx:])
 						if c2 == utf8.RuneError {
@@ -188,11 +225,12 @@ func filterTruncateHTMLHelper(value string, newOutput *bytes.Buffer, cond func()
 							idx++ // consume ">"
 							break
 						}
+						selfClosing = c2 == '/'
 
 						if !params {
 							if c2 == ' ' {
 								params = true
-							} else {
+							} else if c2 != '/' {
 								tag += string(c2)
 							}
 						}
@@ -200,8 +238,11 @@ func filterTruncateHTMLHelper(value string, newOutput *bytes.Buffer, cond func()
 						idx += size2
 					}
 
-					// Add tag to stack
-					tagStack = append(tagStack, tag)
+					// Add tag to stack; void elements (like <br>), self-closing
+					// tags and comments/doctypes don't need to be closed.
+					if !selfClosing && !filterTruncateHTMLVoidElements[strings.ToLower(tag)] && !strings.HasPrefix(tag, "!") {
+						tagStack = append(tagStack, tag)
+					}
 				}
 			}
 		} else {
@@ -248,6 +289,48 @@ func filterTruncatecharsHTML(in *Value, param *Value, bind map[string]any) (*Val
 	return AsSafeValue(newOutput.String()), nil
 }
 
+// filterTruncateHTML truncates HTML after the given amount of visible characters
+// (an entity like &amp; counts as one character). All tags open at the cut
+// point are getting closed and an ellipsis is appended.
+func filterTruncateHTML(in *Value, param *Value, bind map[string]any) (*Value, *Error) {
+	value := in.String()
+	budget := param.Integer()
+	if budget < 0 {
+		return nil, &Error{
+			Sender:    "filter:truncatehtml",
+			OrigError: errors.New("filter truncatehtml requires a non-negative amount of characters as argument"),
+		}
+	}
+
+	newOutput := bytes.NewBuffer(nil)
+
+	textcounter := 0
+	lastIdx := 0
+
+	filterTruncateHTMLHelper(value, newOutput, func() bool {
+		return textcounter >= budget
+	}, func(c rune, s int, idx int) int {
+		textcounter++
+		lastIdx = idx + s
+
+		if c == '&' {
+			// Keep entities as a whole
+			if end := strings.IndexByte(value[idx:], ';'); end > 0 && end <= 10 && !strings.ContainsAny(value[idx+1:idx+end], " <&") {
+				lastIdx = idx + end + 1
+			}
+		}
+		newOutput.WriteString(value[idx:lastIdx])
+
+		return lastIdx
+	}, func() {
+		if textcounter >= budget && strings.TrimSpace(reStriptags.ReplaceAllString(value[lastIdx:], "")) != "" {
+			newOutput.WriteString("...")
+		}
+	})
+
+	return AsSafeValue(newOutput.String()), nil
+}
+
 func filterTruncatewords(in *Value, param *Value, bind map[string]any) (*Value, *Error) {
 	words := strings.Fields(in.String())
 	n := param.Integer()
@@ -327,6 +410,35 @@ func filterEscape(in *Value, param *Value, bind map[string]any) (*Value, *Error)
 	return AsValue(output), nil
 }
 
+var filterEscapeOnceEntityRegexp = regexp.MustCompile(`&(?:[a-zA-Z][a-zA-Z0-9]*|#[0-9]+|#[xX][0-9a-fA-F]+);`)
+
+// filterEscapeOnce escapes the input like escape, but keeps already escaped
+// entities (like &amp; or &#39;) as they are. The result is marked safe.
+func filterEscapeOnce(in *Value, param *Value, bind map[string]any) (*Value, *Error) {
+	var b bytes.Buffer
+
+	s := in.String()
+	last := 0
+	for _, loc := range filterEscapeOnceEntityRegexp.FindAllStringIndex(s, -1) {
+		escaped, _ := filterEscape(AsValue(s[last:loc[0]]), nil, nil)
+		b.WriteString(escaped.String())
+		b.WriteString(s[loc[0]:loc[1]])
+		last = loc[1]
+	}
+	escaped, _ := filterEscape(AsValue(s[last:]), nil, nil)
+	b.WriteString(escaped.String())
+
+	return AsSafeValue(b.String()), nil
+}
+
+// filterForceEscape escapes the input immediately, even if it has been
+// marked safe before. The escaped result is marked safe, so autoescaping
+// doesn't escape it a second time.
+func filterForceEscape(in *Value, param *Value, bind map[string]any) (*Value, *Error) {
+	escaped, _ := filterEscape(in, nil, nil)
+	return AsSafeValue(escaped.String()), nil
+}
+
 func filterSafe(in *Value, param *Value, bind map[string]any) (*Value, *Error) {
 	return in, nil // nothing to do here, just to keep track of the safe application
 }
@@ -370,6 +482,11 @@ func filterEscapejs(in *Value, param *Value, bind map[string]any) (*Value, *Erro
 
 		if (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c == ' ' || c == '/' {
 			b.WriteRune(c)
+		} else if c > 0xFFFF {
+			// JavaScript's \u escapes only take 4 hex digits, so
+			// these must be written as UTF-16 surrogate pair
+			r1, r2 := utf16.EncodeRune(c)
+			b.WriteString(fmt.Sprintf(`\u%04X\u%04X`, r1, r2))
 		} else {
 			b.WriteString(fmt.Sprintf(`\u%04X`, c))
 		}
@@ -377,19 +494,85 @@ func filterEscapejs(in *Value, param *Value, bind map[string]any) (*Value, *Erro
 		idx += size
 	}
 
-	return AsValue(b.String()), nil
+	// The output consists of letters, spaces, slashes and escape sequences
+	// only, therefore it doesn't need to be HTML-escaped anymore.
+	return AsSafeValue(b.String()), nil
 }
 
+// filterAdd adds two numbers (integer strings are taken as numbers, like
+// in Django), appends two lists into a new one or concatenates the string
+// representations of other values (strings, numbers, booleans and nil).
 func filterAdd(in *Value, param *Value, bind map[string]any) (*Value, *Error) {
-	if in.IsNumber() && param.IsNumber() {
-		if in.IsFloat() || param.IsFloat() {
-			return AsValue(in.Float() + param.Float()), nil
+	if a, b := filterAddNumber(in), filterAddNumber(param); a != nil && b != nil {
+		if isBigArithmetic(a, b) {
+			sum, err := bigArithmetic("+", a, b)
+			if err != nil {
+				return nil, &Error{
+					Sender:    "filter:add",
+					OrigError: err,
+				}
+			}
+			return sum, nil
+		}
+		if a.IsFloat() || b.IsFloat() {
+			return AsValue(a.Float() + b.Float()), nil
+		}
+		return AsValue(a.Integer() + b.Integer()), nil
+	}
+
+	if filterAddIsList(in) && filterAddIsList(param) {
+		items := make([]any, 0, in.Len()+param.Len())
+		for i := 0; i < in.Len(); i++ {
+			items = append(items, in.Index(i).Interface())
+		}
+		for i := 0; i < param.Len(); i++ {
+			items = append(items, param.Index(i).Interface())
 		}
-		return AsValue(in.Integer() + param.Integer()), nil
+		return AsValue(items), nil
+	}
+
+	if filterAddIsScalar(in) && filterAddIsScalar(param) {
+		// Relying on the Value's String() conversion and just add them both together
+		return AsValue(in.String() + param.String()), nil
+	}
+
+	return nil, &Error{
+		Sender:    "filter:add",
+		OrigError: fmt.Errorf("cannot add %s and %s", filterAddTypeName(in), filterAddTypeName(param)),
+	}
+}
+
+// filterAddNumber returns v as a number if it is one or if it's a string
+// containing an integer, otherwise nil.
+func filterAddNumber(v *Value) *Value {
+	if v.IsNumber() {
+		return v
+	}
+	if v.IsString() {
+		if i, err := strconv.Atoi(v.String()); err == nil {
+			return AsValue(i)
+		}
+	}
+	return nil
+}
+
+func filterAddIsList(v *Value) bool {
+	switch v.getResolvedValue().Kind() {
+	case reflect.Array, reflect.Slice:
+		return true
+	}
+	return false
+}
+
+func filterAddIsScalar(v *Value) bool {
+	return v.IsNil() || v.IsString() || v.IsNumber() || v.IsBool()
+}
+
+func filterAddTypeName(v *Value) string {
+	if v.IsNil() {
+		return "nil"
 	}
-	// If in/param is not a number, we're relying on the
-	// Value's String() conversion and just add them both together
-	return AsValue(in.String() + param.String()), nil
+	return v.getResolvedValue().Type().String()
 }
 
 func filterAddslashes(in *Value, param *Value, bind map[string]any) (*Value, *Error) {
@@ -399,6 +582,120 @@ func filterAddslashes(in *Value, param *Value, bind map[string]any) (*Value, *Er
 	return AsValue(output), nil
 }
 
+// filterB64Encoding returns the encoding selected by the filter's argument
+// (standard encoding by default, URL encoding with "url").
+func filterB64Encoding(param *Value, sender string) (*base64.Encoding, *Error) {
+	switch param.String() {
+	case "":
+		return base64.StdEncoding, nil
+	case "url":
+		return base64.URLEncoding, nil
+	default:
+		return nil, &Error{
+			Sender:    sender,
+			OrigError: fmt.Errorf("unknown encoding '%s' (only 'url' is supported)", param.String()),
+		}
+	}
+}
+
+func filterB64encode(in *Value, param *Value, bind map[string]any) (*Value, *Error) {
+	enc, err := filterB64Encoding(param, "filter:b64encode")
+	if err != nil {
+		return nil, err
+	}
+
+	if b, ok := in.Interface().([]byte); ok {
+		return AsValue(enc.EncodeToString(b)), nil
+	}
+	return AsValue(enc.EncodeToString([]byte(in.String()))), nil
+}
+
+// filterApply applies the filter named by param to the input, without any
+// argument. The name is resolved at render time, so it may come from a variable.
+func filterApply(ctx *ExecutionContext, in *Value, param *Value) (*Value, *Error) {
+	name := param.String()
+	storedValue, existing := filters.Load(name)
+	if !existing {
+		return nil, &Error{
+			Sender:    "filter:apply",
+			OrigError: fmt.Errorf("filter with name '%s' not found", name),
+		}
+	}
+
+	// The sandbox restriction is checked at parse time for filters named in
+	// the template, but the name given here is only known now
+	if ctx.template != nil && ctx.template.set.isFilterBanned(name) {
+		return nil, &Error{
+			Sender:    "filter:apply",
+			OrigError: fmt.Errorf("usage of filter '%s' is not allowed (sandbox restriction active)", name),
+		}
+	}
+
+	filterFunc, _ := storedValue.(FilterFunction)
+	contextFilterFunc, _ := storedValue.(ContextFilterFunction)
+	return executeFilter(ctx, name, filterFunc, contextFilterFunc, in, AsValue(nil))
+}
+
+func filterB64decode(in *Value, param *Value, bind map[string]any) (*Value, *Error) {
+	enc, err := filterB64Encoding(param, "filter:b64decode")
+	if err != nil {
+		return nil, err
+	}
+
+	b, decodeErr := enc.DecodeString(in.String())
+	if decodeErr != nil {
+		return nil, &Error{
+			Sender:    "filter:b64decode",
+			OrigError: decodeErr,
+		}
+	}
+	return AsValue(string(b)), nil
+}
+
+// filterBatch splits a slice into chunks of the given size. An optional
+// second argument is used to fill up the last chunk.
+func filterBatch(in *Value, param *Value, bind map[string]any) (*Value, *Error) {
+	args := FilterArguments(param)
+	if len(args) == 0 || len(args) > 2 || !args[0].IsInteger() || args[0].Integer() <= 0 {
+		return nil, &Error{
+			Sender:    "filter:batch",
+			OrigError: errors.New("filter batch requires a positive integer size (and an optional fill value) as argument"),
+		}
+	}
+	if !in.CanSlice() {
+		return in, nil
+	}
+	size := args[0].Integer()
+
+	if len(args) == 2 && in.Len() > 0 && size > in.Len() && size > maxRangeLength {
+		return nil, &Error{
+			Sender:    "filter:batch",
+			OrigError: fmt.Errorf("filter batch doesn't support filling batches of more than %d items", maxRangeLength),
+		}
+	}
+
+	count := 0
+	if in.Len() > 0 {
+		// Doesn't overflow for huge sizes (unlike (in.Len()+size-1)/size)
+		count = (in.Len()-1)/size + 1
+	}
+	batches := make([][]any, 0, count)
+	for i := 0; i < in.Len(); i += size {
+		batch := make([]any, 0, min(size, in.Len()-i))
+		for j := i; j-i < size && j < in.Len(); j++ {
+			batch = append(batch, in.Index(j).Interface())
+		}
+		if len(args) == 2 {
+			for len(batch) < size {
+				batch = append(batch, args[1].Interface())
+			}
+		}
+		batches = append(batches, batch)
+	}
+
+	return AsValue(batches), nil
+}
+
 func filterCut(in *Value, param *Value, bind map[string]any) (*Value, *Error) {
 	return AsValue(strings.Replace(in.String(), param.String(), "", -1)), nil
 }
@@ -408,9 +705,30 @@ func filterLength(in *Value, param *Value, bind map[string]any) (*Value, *Error)
 }
 
 func filterLengthis(in *Value, param *Value, bind map[string]any) (*Value, *Error) {
+	if !param.IsInteger() {
+		if _, err := strconv.Atoi(param.String()); err != nil || !param.IsString() {
+			return nil, &Error{
+				Sender:    "filter:length_is",
+				OrigError: errors.New("filter length_is requires an integer as argument"),
+			}
+		}
+	}
 	return AsValue(in.Len() == param.Integer()), nil
 }
 
+// filterIsempty checks whether a string, slice, array, map or channel has no
+// items; nil is empty as well.
+func filterIsempty(in *Value, param *Value, bind map[string]any) (*Value, *Error) {
+	if in.IsNil() {
+		return AsValue(true), nil
+	}
+	switch in.getResolvedValue().Kind() {
+	case reflect.String, reflect.Slice, reflect.Array, reflect.Map, reflect.Chan:
+		return AsValue(in.getResolvedValue().Len() == 0), nil
+	}
+	return AsValue(false), nil
+}
+
 func filterDefault(in *Value, param *Value, bind map[string]any) (*Value, *Error) {
 	if !in.IsTrue() {
 		return param, nil
@@ -425,20 +743,152 @@ func filterDefaultIfNone(in *Value, param *Value, bind map[string]any) (*Value,
 	return in, nil
 }
 
+// filterDictsort sorts a list of maps or structs by the given key or field
+// name (which may be a dotted path like "author.name").
+func filterDictsort(in *Value, param *Value, bind map[string]any) (*Value, *Error) {
+	return filterDictsortHelper(in, param, false), nil
+}
+
+func filterDictsortreversed(in *Value, param *Value, bind map[string]any) (*Value, *Error) {
+	return filterDictsortHelper(in, param, true), nil
+}
+
+func filterDictsortHelper(in *Value, param *Value, reverse bool) *Value {
+	if !in.CanSlice() || in.IsString() {
+		return in
+	}
+	attr := param.String()
+
+	items := make([]any, 0, in.Len())
+	keys := make([]*Value, 0, in.Len())
+	for i := 0; i < in.Len(); i++ {
+		item := in.Index(i)
+		items = append(items, item.Interface())
+		keys = append(keys, item.getAttribute(attr))
+	}
+
+	idx := make([]int, len(items))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(i, j int) bool {
+		if reverse {
+			return filterDictsortLess(keys[idx[j]], keys[idx[i]])
+		}
+		return filterDictsortLess(keys[idx[i]], keys[idx[j]])
+	})
+
+	sorted := make([]any, 0, len(items))
+	for _, i := range idx {
+		sorted = append(sorted, items[i])
+	}
+	return AsValue(sorted)
+}
+
+// filterDictsortLess compares two sort keys. Numbers are compared by their
+// value, everything else by its string representation. Missing keys (nil)
+// sort like zero among numbers and like an empty string otherwise.
+func filterDictsortLess(a, b *Value) bool {
+	if a.IsNil() && b.IsNumber() {
+		a = AsValue(0)
+	}
+	if b.IsNil() && a.IsNumber() {
+		b = AsValue(0)
+	}
+	if a.IsNumber() && b.IsNumber() && !isBigArithmetic(a, b) && !(a.IsInteger() && b.IsInteger()) {
+		return a.Float() < b.Float()
+	}
+	return valueLess(a, b)
+}
+
 func filterDivisibleby(in *Value, param *Value, bind map[string]any) (*Value, *Error) {
+	if isBigArithmetic(in, param) {
+		if param.BigInt().Sign() == 0 {
+			return AsValue(false), nil
+		}
+		return AsValue(new(big.Int).Rem(in.BigInt(), param.BigInt()).Sign() == 0), nil
+	}
 	if param.Integer() == 0 {
 		return AsValue(false), nil
 	}
 	return AsValue(in.Integer()%param.Integer() == 0), nil
 }
 
+// filterFilesizeformat formats a byte count human-readable (e. g. "13.0 KB", "4.1 MB").
+// The argument selects 1000-based units ("decimal"; the default) or 1024-based
+// IEC units ("binary").
+func filterFilesizeformat(in *Value, param *Value, bind map[string]any) (*Value, *Error) {
+	var base float64
+	var units []string
+
+	switch param.String() {
+	case "", "decimal":
+		base = 1000
+		units = []string{"KB", "MB", "GB", "TB", "PB", "EB"}
+	case "binary":
+		base = 1024
+		units = []string{"KiB", "MiB", "GiB", "TiB", "PiB", "EiB"}
+	default:
+		return nil, &Error{
+			Sender:    "filter:filesizeformat",
+			OrigError: fmt.Errorf("unknown mode '%s' (must be either 'decimal' or 'binary')", param.String()),
+		}
+	}
+
+	size := in.Float()
+	sign := ""
+	if size < 0 {
+		sign = "-"
+		size = -size
+	}
+
+	if size < base {
+		if size == 1 {
+			return AsValue(sign + "1 byte"), nil
+		}
+		return AsValue(fmt.Sprintf("%s%d bytes", sign, int64(size))), nil
+	}
+
+	// Move on to the next unit as well if the size would round up to the base
+	// (999999 bytes are "1.0 MB", not "1000.0 KB").
+	unit := -1
+	for math.Round(size*10)/10 >= base && unit < len(units)-1 {
+		size /= base
+		unit++
+	}
+	return AsValue(fmt.Sprintf("%s%.1f %s", sign, size, units[unit])), nil
+}
+
+// filterFirst returns the first item of a slice or array, the first character
+// of a string or, for a map, the value of its first key in sorted order. Empty
+// (or nil) inputs return nil.
 func filterFirst(in *Value, param *Value, bind map[string]any) (*Value, *Error) {
-	if in.CanSlice() && in.Len() > 0 {
+	switch {
+	case in.IsNil() || ((in.CanSlice() || in.getResolvedValue().Kind() == reflect.Map) && in.Len() == 0):
+		return AsValue(nil), nil
+	case in.CanSlice():
 		return in.Index(0), nil
+	case in.getResolvedValue().Kind() == reflect.Map:
+		return filterFirstLastMapValue(in, false), nil
 	}
 	return AsValue(""), nil
 }
 
+// filterFirstLastMapValue returns the value of the first (or last) key of a
+// non-empty map in sorted order (like the sort-filter sorts them).
+func filterFirstLastMapValue(in *Value, last bool) *Value {
+	m := in.getResolvedValue()
+	keys := m.MapKeys()
+	sort.SliceStable(keys, func(i, j int) bool {
+		return valueLess(AsValue(keys[i].Interface()), AsValue(keys[j].Interface()))
+	})
+	key := keys[0]
+	if last {
+		key = keys[len(keys)-1]
+	}
+	return AsValue(m.MapIndex(key).Interface())
+}
+
 const maxFloatFormatDecimals = 1000
 
 func filterFloatformat(in *Value, param *Value, bind map[string]any) (*Value, *Error) {
@@ -478,6 +928,48 @@ func filterFloatformat(in *Value, param *Value, bind map[string]any) (*Value, *E
 	return AsValue(strconv.FormatFloat(val, 'f', decimals, 64)), nil
 }
 
+// filterGet returns the value of a map's key or a struct's (exported) field;
+// it never errors but returns nil if there's no such key or field.
+func filterGet(in *Value, param *Value, bind map[string]any) (*Value, *Error) {
+	base := in.getResolvedValue()
+	switch base.Kind() {
+	case reflect.Map:
+		if !param.val.IsValid() {
+			return AsValue(nil), nil
+		}
+		key := param.val
+		if !key.Type().Comparable() {
+			// Can't be a map key (e. g. a slice), MapIndex would panic
+			return AsValue(nil), nil
+		}
+		keyType := base.Type().Key()
+		if !key.Type().AssignableTo(keyType) {
+			// Allows named key types (like type Key string)
+			if key.Kind() != keyType.Kind() || !key.Type().ConvertibleTo(keyType) {
+				return AsValue(nil), nil
+			}
+			key = key.Convert(keyType)
+		}
+		value := base.MapIndex(key)
+		if !value.IsValid() {
+			return AsValue(nil), nil
+		}
+		return AsValue(value.Interface()), nil
+	case reflect.Struct:
+		field, has := base.Type().FieldByName(param.String())
+		if !has || field.PkgPath != "" {
+			return AsValue(nil), nil
+		}
+		value, err := base.FieldByIndexErr(field.Index)
+		if err != nil {
+			// A nil embedded struct pointer
+			return AsValue(nil), nil
+		}
+		return AsValue(value.Interface()), nil
+	}
+	return AsValue(nil), nil
+}
+
 func filterGetdigit(in *Value, param *Value, bind map[string]any) (*Value, *Error) {
 	i := param.Integer()
 	l := len(in.String()) // do NOT use in.Len() here!
@@ -489,6 +981,48 @@ func filterGetdigit(in *Value, param *Value, bind map[string]any) (*Value, *Erro
 
 const filterIRIChars = "/#%[]=:;$&()+,!?*@'~"
 
+// filterGroupBy groups the items of a slice by the given attribute. It returns
+// a list of maps containing the keys "grouper" (the attribute's value) and
+// "list" (all items having this value) in order of first appearance.
+func filterGroupBy(in *Value, param *Value, bind map[string]any) (*Value, *Error) {
+	if !in.CanSlice() {
+		return in, nil
+	}
+	attr := param.String()
+
+	var groupers []*Value
+	var lists [][]any
+
+	for i := 0; i < in.Len(); i++ {
+		item := in.Index(i)
+		// Items without the attribute are grouped under a nil grouper
+		grouper := item.getAttribute(attr)
+
+		idx := -1
+		for gi, g := range groupers {
+			if (g.IsNil() && grouper.IsNil()) || g.EqualValueTo(grouper) {
+				idx = gi
+				break
+			}
+		}
+		if idx < 0 {
+			groupers = append(groupers, grouper)
+			lists = append(lists, nil)
+			idx = len(groupers) - 1
+		}
+		lists[idx] = append(lists[idx], item.Interface())
+	}
+
+	groups := make([]map[string]any, 0, len(groupers))
+	for gi, g := range groupers {
+		groups = append(groups, map[string]any{
+			"grouper": g.Interface(),
+			"list":    lists[gi],
+		})
+	}
+	return AsValue(groups), nil
+}
+
 func filterIriencode(in *Value, param *Value, bind map[string]any) (*Value, *Error) {
 	var b bytes.Buffer
 
@@ -504,12 +1038,26 @@ func filterIriencode(in *Value, param *Value, bind map[string]any) (*Value, *Err
 	return AsValue(b.String()), nil
 }
 
+// filterJoin joins the items with the separator given as argument. An optional
+// second argument is used as the separator before the last item instead:
+//
+//	{{ names|join:", "," and " }} => "Alice, Bob and Carol"
 func filterJoin(in *Value, param *Value, bind map[string]any) (*Value, *Error) {
+	args := FilterArguments(param)
+	if len(args) > 2 {
+		return nil, &Error{
+			Sender:    "filter:join",
+			OrigError: errors.New("filter join takes a separator and an optional last separator as arguments"),
+		}
+	}
 	if !in.CanSlice() {
 		return in, nil
 	}
-	sep := param.String()
-	if sep == "" {
+	var sep string
+	if len(args) > 0 {
+		sep = args[0].String()
+	}
+	if sep == "" && len(args) < 2 {
 		// An empty string separator returns the input string.
 		return AsValue(in.String()), nil
 	}
@@ -529,16 +1077,89 @@ func filterJoin(in *Value, param *Value, bind map[string]any) (*Value, *Error) {
 		}
 	}
 
+	if len(args) == 2 && len(sl) > 1 {
+		last := len(sl) - 1
+		return AsValue(strings.Join(sl[:last], sep) + args[1].String() + sl[last]), nil
+	}
 	return AsValue(strings.Join(sl, sep)), nil
 }
 
+// filterJSON encodes the input as JSON (indented by the argument's number of
+// spaces, if given). It's meant to be embedded in a <script> element; the
+// result is marked safe, as <, >, & and ' are escaped as unicode sequences.
+func filterJSON(in *Value, param *Value, bind map[string]any) (*Value, *Error) {
+	var b bytes.Buffer
+
+	enc := json.NewEncoder(&b)
+	// The encoder escapes <, > and & (as \u003c, \u003e and \u0026), but not '.
+	enc.SetEscapeHTML(true)
+	if indent := param.Integer(); indent > 0 {
+		enc.SetIndent("", strings.Repeat(" ", indent))
+	}
+
+	if err := enc.Encode(in.Interface()); err != nil {
+		return nil, &Error{
+			Sender:    "filter:json",
+			OrigError: err,
+		}
+	}
+
+	// Encode() always appends a newline. A ' can only occur within a JSON
+	// string, where \u0027 is equivalent; it would end a single-quoted attribute.
+	out := strings.TrimSuffix(b.String(), "\n")
+	return AsSafeValue(strings.ReplaceAll(out, "'", `\u0027`)), nil
+}
+
+// filterLast works like first, but returns the last item (character, value).
 func filterLast(in *Value, param *Value, bind map[string]any) (*Value, *Error) {
-	if in.CanSlice() && in.Len() > 0 {
+	switch {
+	case in.IsNil() || ((in.CanSlice() || in.getResolvedValue().Kind() == reflect.Map) && in.Len() == 0):
+		return AsValue(nil), nil
+	case in.CanSlice():
 		return in.Index(in.Len() - 1), nil
+	case in.getResolvedValue().Kind() == reflect.Map:
+		return filterFirstLastMapValue(in, true), nil
 	}
 	return AsValue(""), nil
 }
 
+func filterUnique(in *Value, param *Value, bind map[string]any) (*Value, *Error) {
+	if !in.CanSlice() {
+		return in, nil
+	}
+	attr := param.String()
+
+	seen := make(map[any]struct{})
+	unique := make([]any, 0, in.Len())
+
+	for i := 0; i < in.Len(); i++ {
+		item := in.Index(i)
+		key := item
+		if attr != "" {
+			key = item.getAttribute(attr)
+		}
+
+		// Non-comparable values (like slices or maps) are compared
+		// by their string representation.
+		var k any = key.Interface()
+		if v, ok := k.(*Value); ok {
+			// Items of in-template arrays
+			k = v.Interface()
+		}
+		if k != nil && !reflect.TypeOf(k).Comparable() {
+			k = fmt.Sprintf("%T:%v", k, k)
+		}
+
+		if _, has := seen[k]; has {
+			continue
+		}
+		seen[k] = struct{}{}
+		unique = append(unique, item.Interface())
+	}
+
+	return AsValue(unique), nil
+}
+
 func filterUpper(in *Value, param *Value, bind map[string]any) (*Value, *Error) {
 	return AsValue(strings.ToUpper(in.String())), nil
 }
@@ -567,9 +1188,72 @@ func filterCapfirst(in *Value, param *Value, bind map[string]any) (*Value, *Erro
 
 const maxCharPadding = 10000
 
+// filterWideRunes holds the (main) East Asian wide and fullwidth characters
+// which take two columns in a monospace terminal.
+var filterWideRunes = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{Lo: 0x1100, Hi: 0x115f, Stride: 1}, // Hangul Jamo
+		{Lo: 0x2e80, Hi: 0x303e, Stride: 1}, // CJK radicals, symbols and punctuation
+		{Lo: 0x3041, Hi: 0x33ff, Stride: 1}, // Hiragana, Katakana, Bopomofo, ...
+		{Lo: 0x3400, Hi: 0x4dbf, Stride: 1}, // CJK unified ideographs extension A
+		{Lo: 0x4e00, Hi: 0x9fff, Stride: 1}, // CJK unified ideographs
+		{Lo: 0xa000, Hi: 0xa4cf, Stride: 1}, // Yi
+		{Lo: 0xac00, Hi: 0xd7a3, Stride: 1}, // Hangul syllables
+		{Lo: 0xf900, Hi: 0xfaff, Stride: 1}, // CJK compatibility ideographs
+		{Lo: 0xfe30, Hi: 0xfe4f, Stride: 1}, // CJK compatibility forms
+		{Lo: 0xff00, Hi: 0xff60, Stride: 1}, // Fullwidth forms
+		{Lo: 0xffe0, Hi: 0xffe6, Stride: 1}, // Fullwidth signs
+	},
+	R32: []unicode.Range32{
+		{Lo: 0x1f300, Hi: 0x1f64f, Stride: 1}, // Emoji and pictographs
+		{Lo: 0x1f900, Hi: 0x1f9ff, Stride: 1}, // Supplemental symbols and pictographs
+		{Lo: 0x20000, Hi: 0x2fffd, Stride: 1}, // CJK unified ideographs extension B-F
+		{Lo: 0x30000, Hi: 0x3fffd, Stride: 1}, // CJK unified ideographs extension G-
+	},
+}
+
+// filterDisplayWidth returns the number of columns s takes in a monospace
+// terminal.
+func filterDisplayWidth(s string) int {
+	width := 0
+	for _, r := range s {
+		if unicode.Is(filterWideRunes, r) {
+			width += 2
+		} else {
+			width++
+		}
+	}
+	return width
+}
+
+// filterPaddingArguments returns the width the input of center, ljust or
+// rjust has to be padded to and the input's current width. The latter counts
+// characters, or display columns if the "wide" flag is given as second
+// argument:
+//
+//	{{ value|ljust:10,"wide" }}
+func filterPaddingArguments(name string, in *Value, param *Value) (width int, inWidth int, wide bool, err *Error) {
+	args := FilterArguments(param)
+	if len(args) > 2 || (len(args) == 2 && args[1].String() != "wide") {
+		return 0, 0, false, &Error{
+			Sender:    "filter:" + name,
+			OrigError: fmt.Errorf("filter %s takes a width and an optional \"wide\" flag as arguments", name),
+		}
+	}
+	if len(args) > 0 {
+		width = args[0].Integer()
+	}
+	if len(args) == 2 {
+		return width, filterDisplayWidth(in.String()), true, nil
+	}
+	return width, in.Len(), false, nil
+}
+
 func filterCenter(in *Value, param *Value, bind map[string]any) (*Value, *Error) {
-	width := param.Integer()
-	slen := in.Len()
+	width, slen, _, err := filterPaddingArguments("center", in, param)
+	if err != nil {
+		return nil, err
+	}
 	if width <= slen {
 		return in, nil
 	}
@@ -590,6 +1274,171 @@ func filterCenter(in *Value, param *Value, bind map[string]any) (*Value, *Error)
 		in.String(), strings.Repeat(" ", right))), nil
 }
 
+// filterDateDjangoPrefix marks a layout as a Django-style format (for example
+// "django:Y-m-d"); any other layout is a Go reference layout.
+const filterDateDjangoPrefix = "django:"
+
+// filterDateFormat formats t using layout, which is either a Go reference layout
+// or a Django-style format prefixed by filterDateDjangoPrefix.
+func filterDateFormat(t time.Time, layout string) string {
+	if strings.HasPrefix(layout, filterDateDjangoPrefix) {
+		return filterDateDjangoFormat(t, strings.TrimPrefix(layout, filterDateDjangoPrefix))
+	}
+	return t.Format(layout)
+}
+
+// filterDateDjangoFormat formats t using Django's date format characters. Supported are:
+//
+//	d  day of the month, 2 digits with leading zeros ("01" to "31")
+//	j  day of the month without leading zeros ("1" to "31")
+//	D  day of the week, textual, 3 letters ("Fri")
+//	l  day of the week, textual, long ("Friday")
+//	S  English ordinal suffix for day of the month ("st", "nd", "rd" or "th")
+//	w  day of the week, digits without leading zeros ("0" (Sunday) to "6" (Saturday))
+//	z  day of the year ("1" to "366")
+//	W  ISO-8601 week number of year
+//	m  month, 2 digits with leading zeros ("01" to "12")
+//	n  month without leading zeros ("1" to "12")
+//	M  month, textual, 3 letters ("Jan")
+//	b  month, textual, 3 letters, lowercase ("jan")
+//	F  month, textual, long ("January")
+//	t  number of days in the given month ("28" to "31")
+//	L  whether it's a leap year ("True" or "False")
+//	y  year, 2 digits ("99")
+//	Y  year, 4 digits ("1999")
+//	o  ISO-8601 week-numbering year
+//	a  "a.m." or "p.m."
+//	A  "AM" or "PM"
+//	g  hour, 12-hour format without leading zeros ("1" to "12")
+//	G  hour, 24-hour format without leading zeros ("0" to "23")
+//	h  hour, 12-hour format ("01" to "12")
+//	H  hour, 24-hour format ("00" to "23")
+//	i  minutes ("00" to "59")
+//	s  seconds, 2 digits with leading zeros ("00" to "59")
+//	u  microseconds ("000000" to "999999")
+//	e  timezone name ("UTC", "CET")
+//	T  timezone abbreviation ("EST", "MDT")
+//	O  difference to Greenwich time in hours ("+0200")
+//	Z  timezone offset in seconds ("-43200" to "43200")
+//	c  ISO 8601 format ("2008-01-02T10:30:00.000123+02:00")
+//	r  RFC 5322 formatted date ("Thu, 21 Dec 2000 16:01:07 +0200")
+//	U  seconds since the Unix epoch
+//
+// Any other character is copied as is; a backslash escapes a format character.
+func filterDateDjangoFormat(t time.Time, format string) string {
+	var b strings.Builder
+
+	escaped := false
+	for _, c := range format {
+		if escaped {
+			b.WriteRune(c)
+			escaped = false
+			continue
+		}
+
+		switch c {
+		case '\\':
+			escaped = true
+		case 'd':
+			b.WriteString(t.Format("02"))
+		case 'j':
+			b.WriteString(strconv.Itoa(t.Day()))
+		case 'D':
+			b.WriteString(t.Format("Mon"))
+		case 'l':
+			b.WriteString(t.Format("Monday"))
+		case 'S':
+			switch day := t.Day(); {
+			case day == 1 || day == 21 || day == 31:
+				b.WriteString("st")
+			case day == 2 || day == 22:
+				b.WriteString("nd")
+			case day == 3 || day == 23:
+				b.WriteString("rd")
+			default:
+				b.WriteString("th")
+			}
+		case 'w':
+			b.WriteString(strconv.Itoa(int(t.Weekday())))
+		case 'z':
+			b.WriteString(strconv.Itoa(t.YearDay()))
+		case 'W':
+			_, week := t.ISOWeek()
+			b.WriteString(strconv.Itoa(week))
+		case 'm':
+			b.WriteString(t.Format("01"))
+		case 'n':
+			b.WriteString(strconv.Itoa(int(t.Month())))
+		case 'M':
+			b.WriteString(t.Format("Jan"))
+		case 'b':
+			b.WriteString(strings.ToLower(t.Format("Jan")))
+		case 'F':
+			b.WriteString(t.Format("January"))
+		case 't':
+			b.WriteString(strconv.Itoa(time.Date(t.Year(), t.Month()+1, 0, 0, 0, 0, 0, t.Location()).Day()))
+		case 'L':
+			if year := t.Year(); year%4 == 0 && (year%100 != 0 || year%400 == 0) {
+				b.WriteString("True")
+			} else {
+				b.WriteString("False")
+			}
+		case 'y':
+			b.WriteString(t.Format("06"))
+		case 'Y':
+			b.WriteString(t.Format("2006"))
+		case 'o':
+			year, _ := t.ISOWeek()
+			b.WriteString(strconv.Itoa(year))
+		case 'a':
+			if t.Hour() < 12 {
+				b.WriteString("a.m.")
+			} else {
+				b.WriteString("p.m.")
+			}
+		case 'A':
+			b.WriteString(t.Format("PM"))
+		case 'g':
+			b.WriteString(t.Format("3"))
+		case 'G':
+			b.WriteString(strconv.Itoa(t.Hour()))
+		case 'h':
+			b.WriteString(t.Format("03"))
+		case 'H':
+			b.WriteString(t.Format("15"))
+		case 'i':
+			b.WriteString(t.Format("04"))
+		case 's':
+			b.WriteString(t.Format("05"))
+		case 'u':
+			b.WriteString(fmt.Sprintf("%06d", t.Nanosecond()/1000))
+		case 'e':
+			b.WriteString(t.Location().String())
+		case 'T':
+			b.WriteString(t.Format("MST"))
+		case 'O':
+			b.WriteString(t.Format("-0700"))
+		case 'Z':
+			_, offset := t.Zone()
+			b.WriteString(strconv.Itoa(offset))
+		case 'c':
+			b.WriteString(t.Format("2006-01-02T15:04:05.000000-07:00"))
+		case 'r':
+			b.WriteString(t.Format("Mon, 02 Jan 2006 15:04:05 -0700"))
+		case 'U':
+			b.WriteString(strconv.FormatInt(t.Unix(), 10))
+		default:
+			b.WriteRune(c)
+		}
+	}
+
+	return b.String()
+}
+
+// filterDate formats a time.Time using either a Go reference layout or, with the
+// "django:" prefix, Django's format characters (see filterDateDjangoFormat). The time is converted into
+// the timezone given as second argument or by bind["tz"] (a *time.Location or
+// a timezone name) beforehand.
 func filterDate(in *Value, param *Value, bind map[string]any) (*Value, *Error) {
 	t, isTime := in.Interface().(time.Time)
 	if !isTime {
@@ -598,11 +1447,200 @@ func filterDate(in *Value, param *Value, bind map[string]any) (*Value, *Error) {
 			OrigError: errors.New("filter input argument must be of type 'time.Time'"),
 		}
 	}
-	return AsValue(t.Format(param.String())), nil
-}
 
-func filterFloat(in *Value, param *Value, bind map[string]any) (*Value, *Error) {
-	return AsValue(in.Float()), nil
+	var layout string
+	var tz any
+	args := FilterArguments(param)
+	if len(args) > 0 {
+		layout = args[0].String()
+	}
+	if len(args) > 1 {
+		tz = args[1].String()
+	} else if bindTz, has := bind["tz"]; has {
+		tz = bindTz
+	}
+
+	switch loc := tz.(type) {
+	case *time.Location:
+		t = t.In(loc)
+	case string:
+		l, err := time.LoadLocation(loc)
+		if err != nil {
+			return nil, &Error{
+				Sender:    "filter:date",
+				OrigError: fmt.Errorf("invalid timezone '%s': %v", loc, err),
+			}
+		}
+		t = t.In(l)
+	}
+
+	return AsValue(filterDateFormat(t, layout)), nil
+}
+
+var filterTimesinceChunks = []struct {
+	d        time.Duration
+	singular string
+	plural   string
+}{
+	{365 * 24 * time.Hour, "year", "years"},
+	{30 * 24 * time.Hour, "month", "months"},
+	{7 * 24 * time.Hour, "week", "weeks"},
+	{24 * time.Hour, "day", "days"},
+	{time.Hour, "hour", "hours"},
+	{time.Minute, "minute", "minutes"},
+}
+
+// filterTimesinceHelper humanizes the duration between from and to using the two
+// largest adjacent units (e. g. "2 days, 4 hours"). Negative durations result in
+// "0 minutes".
+func filterTimesinceHelper(name string, from *Value, param *Value, bind map[string]any, until bool) (*Value, *Error) {
+	t, isTime := from.Interface().(time.Time)
+	if !isTime {
+		return nil, &Error{
+			Sender:    "filter:" + name,
+			OrigError: errors.New("filter input argument must be of type 'time.Time'"),
+		}
+	}
+
+	// The reference time is either given as argument, by bind["now"] or the current time
+	now := time.Now()
+	if !param.IsNil() {
+		ref, isTime := param.Interface().(time.Time)
+		if !isTime {
+			return nil, &Error{
+				Sender:    "filter:" + name,
+				OrigError: errors.New("filter argument must be of type 'time.Time'"),
+			}
+		}
+		now = ref
+	} else if bindNow, isTime := bind["now"].(time.Time); isTime {
+		now = bindNow
+	}
+
+	d := now.Sub(t)
+	if until {
+		d = -d
+	}
+	if d < 0 {
+		d = 0
+	}
+
+	plural := func(n int64, idx int) string {
+		if n == 1 {
+			return fmt.Sprintf("%d %s", n, filterTimesinceChunks[idx].singular)
+		}
+		return fmt.Sprintf("%d %s", n, filterTimesinceChunks[idx].plural)
+	}
+
+	for idx, chunk := range filterTimesinceChunks {
+		count := int64(d / chunk.d)
+		if count == 0 {
+			continue
+		}
+		result := plural(count, idx)
+		if idx+1 < len(filterTimesinceChunks) {
+			next := filterTimesinceChunks[idx+1]
+			if count2 := int64((d - time.Duration(count)*chunk.d) / next.d); count2 > 0 {
+				result += ", " + plural(count2, idx+1)
+			}
+		}
+		return AsValue(result), nil
+	}
+
+	return AsValue("0 minutes"), nil
+}
+
+func filterTimesince(in *Value, param *Value, bind map[string]any) (*Value, *Error) {
+	return filterTimesinceHelper("timesince", in, param, bind, false)
+}
+
+func filterTimeuntil(in *Value, param *Value, bind map[string]any) (*Value, *Error) {
+	return filterTimesinceHelper("timeuntil", in, param, bind, true)
+}
+
+var filterDurationChunks = []struct {
+	d        time.Duration
+	singular string
+	plural   string
+}{
+	{24 * time.Hour, "day", "days"},
+	{time.Hour, "hour", "hours"},
+	{time.Minute, "minute", "minutes"},
+	{time.Second, "second", "seconds"},
+	{time.Millisecond, "millisecond", "milliseconds"},
+}
+
+var filterDurationUnits = map[string]time.Duration{
+	"days":         24 * time.Hour,
+	"hours":        time.Hour,
+	"minutes":      time.Minute,
+	"seconds":      time.Second,
+	"milliseconds": time.Millisecond,
+}
+
+// filterDuration formats a time.Duration (or a string parsable by
+// time.ParseDuration). Given a unit as argument (days, hours, minutes, seconds
+// or milliseconds) it returns the number of whole units, otherwise
+// the humanized duration (e. g. "1 hour 30 minutes").
+func filterDuration(in *Value, param *Value, bind map[string]any) (*Value, *Error) {
+	var d time.Duration
+	switch v := in.Interface().(type) {
+	case time.Duration:
+		d = v
+	case string:
+		parsed, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, &Error{
+				Sender:    "filter:duration",
+				OrigError: err,
+			}
+		}
+		d = parsed
+	default:
+		return nil, &Error{
+			Sender:    "filter:duration",
+			OrigError: errors.New("filter input argument must be of type 'time.Duration'"),
+		}
+	}
+
+	if unit := param.String(); unit != "" {
+		unitDuration, has := filterDurationUnits[unit]
+		if !has {
+			return nil, &Error{
+				Sender:    "filter:duration",
+				OrigError: fmt.Errorf("unknown unit '%s' (must be one of days, hours, minutes, seconds or milliseconds)", unit),
+			}
+		}
+		return AsValue(int64(d / unitDuration)), nil
+	}
+
+	sign := ""
+	if d < 0 {
+		sign = "-"
+		d = -d
+	}
+
+	var parts []string
+	for _, chunk := range filterDurationChunks {
+		count := int64(d / chunk.d)
+		if count == 0 {
+			continue
+		}
+		d -= time.Duration(count) * chunk.d
+		if count == 1 {
+			parts = append(parts, fmt.Sprintf("%d %s", count, chunk.singular))
+		} else {
+			parts = append(parts, fmt.Sprintf("%d %s", count, chunk.plural))
+		}
+	}
+	if len(parts) == 0 {
+		return AsValue("0 seconds"), nil
+	}
+	return AsValue(sign + strings.Join(parts, " ")), nil
+}
+
+func filterFloat(in *Value, param *Value, bind map[string]any) (*Value, *Error) {
+	return AsValue(in.Float()), nil
 }
 
 func filterInteger(in *Value, param *Value, bind map[string]any) (*Value, *Error) {
@@ -654,15 +1692,38 @@ func filterLinebreaks(in *Value, param *Value, bind map[string]any) (*Value, *Er
 }
 
 func filterSplit(in *Value, param *Value, bind map[string]any) (*Value, *Error) {
-	chunks := strings.Split(in.String(), param.String())
+	s := in.String()
+	if s == "" {
+		return AsValue([]string{}), nil
+	}
+	chunks := strings.Split(s, param.String())
 
 	return AsValue(chunks), nil
 }
 
+func filterTrim(in *Value, param *Value, bind map[string]any) (*Value, *Error) {
+	if param.IsNil() {
+		return AsValue(strings.TrimSpace(in.String())), nil
+	}
+	return AsValue(strings.Trim(in.String(), param.String())), nil
+}
+
 func filterLinebreaksbr(in *Value, param *Value, bind map[string]any) (*Value, *Error) {
 	return AsValue(strings.Replace(in.String(), "\n", "<br />", -1)), nil
 }
 
+// filterNl2br inserts a <br> before every line break (\n or \r\n). The
+// result is safe, so the text itself gets escaped unless it's safe already.
+func filterNl2br(in *Value, param *Value, bind map[string]any) (*Value, *Error) {
+	text := in
+	if !in.safe {
+		text, _ = filterEscape(in, nil, nil)
+	}
+	output := strings.Replace(text.String(), "\r\n", "\n", -1)
+	output = strings.Replace(output, "\n", "<br>\n", -1)
+	return AsSafeValue(output), nil
+}
+
 func filterLinenumbers(in *Value, param *Value, bind map[string]any) (*Value, *Error) {
 	lines := strings.Split(in.String(), "\n")
 	output := make([]string, 0, len(lines))
@@ -673,7 +1734,11 @@ func filterLinenumbers(in *Value, param *Value, bind map[string]any) (*Value, *E
 }
 
 func filterLjust(in *Value, param *Value, bind map[string]any) (*Value, *Error) {
-	times := param.Integer() - in.Len()
+	width, inWidth, _, err := filterPaddingArguments("ljust", in, param)
+	if err != nil {
+		return nil, err
+	}
+	times := width - inWidth
 	if times < 0 {
 		times = 0
 	}
@@ -690,101 +1755,194 @@ func filterUrlencode(in *Value, param *Value, bind map[string]any) (*Value, *Err
 	return AsValue(url.QueryEscape(in.String())), nil
 }
 
-// TODO: This regexp could do some work
 var (
-	filterUrlizeURLRegexp   = regexp.MustCompile(`((((http|https)://)|www\.|((^|[ ])[0-9A-Za-z_\-]+(\.com|\.net|\.org|\.info|\.biz|\.de))))(?U:.*)([ ]+|$)`)
-	filterUrlizeEmailRegexp = regexp.MustCompile(`(\w+@\w+\.\w{2,4})`)
+	filterUrlizeWordRegexp   = regexp.MustCompile(`\s+|\S+`)
+	filterUrlizeDomainRegexp = regexp.MustCompile(`^[0-9A-Za-z_\-]+(\.[0-9A-Za-z_\-]+)*\.(com|net|org|info|biz|de)(/.*)?$`)
+	filterUrlizeEmailRegexp  = regexp.MustCompile(`^[\w.+\-]+@[\w\-]+(\.[\w\-]+)*\.[A-Za-z]{2,}$`)
 )
 
-func filterUrlizeHelper(input string, autoescape bool, trunc int) (string, error) {
-	var soutErr error
-	sout := filterUrlizeURLRegexp.ReplaceAllStringFunc(input, func(raw_url string) string {
-		var prefix string
-		var suffix string
-		if strings.HasPrefix(raw_url, " ") {
-			prefix = " "
-		}
-		if strings.HasSuffix(raw_url, " ") {
-			suffix = " "
-		}
-
-		raw_url = strings.TrimSpace(raw_url)
-
-		t, err := ApplyFilter("iriencode", AsValue(raw_url), nil, nil)
-		if err != nil {
-			soutErr = err
-			return ""
-		}
-		url := t.String()
-
-		if !strings.HasPrefix(url, "http") {
-			url = fmt.Sprintf("http://%s", url)
-		}
-
-		title := raw_url
-
-		if trunc > 3 && len(title) > trunc {
-			title = fmt.Sprintf("%s...", title[:trunc-3])
-		}
+const (
+	filterUrlizeLeadingPunctuation  = "([<\"'"
+	filterUrlizeTrailingPunctuation = ".,:;!?"
+)
 
-		if autoescape {
-			t, err := ApplyFilter("escape", AsValue(title), nil, nil)
-			if err != nil {
-				soutErr = err
-				return ""
+// filterUrlizeSplitPunctuation splits the punctuation surrounding a word
+// (like in "(see example.com)." or a trailing comma) off the word. A closing
+// bracket or quote is only split off if it has no counterpart within the word.
+func filterUrlizeSplitPunctuation(word string) (lead, middle, trail string) {
+	middle = strings.TrimLeft(word, filterUrlizeLeadingPunctuation)
+	lead = word[:len(word)-len(middle)]
+
+	for middle != "" {
+		last := middle[len(middle)-1]
+		if strings.IndexByte(filterUrlizeTrailingPunctuation, last) < 0 {
+			switch last {
+			case ')', ']', '>':
+				opening := "([<"[strings.IndexByte(")]>", last)]
+				if strings.Count(middle, string(opening)) >= strings.Count(middle, string(last)) {
+					return lead, middle, trail
+				}
+			case '"', '\'':
+				if strings.Count(middle, string(last))%2 == 0 {
+					return lead, middle, trail
+				}
+			default:
+				return lead, middle, trail
 			}
-			title = t.String()
 		}
-
-		return fmt.Sprintf(`%s<a href="%s" rel="nofollow">%s</a>%s`, prefix, url, title, suffix)
-	})
-	if soutErr != nil {
-		return "", soutErr
+		trail = middle[len(middle)-1:] + trail
+		middle = middle[:len(middle)-1]
 	}
+	return lead, middle, trail
+}
 
-	sout = filterUrlizeEmailRegexp.ReplaceAllStringFunc(sout, func(mail string) string {
-		title := mail
-
-		if trunc > 3 && len(title) > trunc {
-			title = fmt.Sprintf("%s...", title[:trunc-3])
+// filterUrlizeHelper converts all URLs and email addresses within the input
+// into links. The link texts are truncated to trunc characters (if trunc > 0);
+// if autoescape is true, the input's remaining text gets escaped as well.
+func filterUrlizeHelper(input string, autoescape bool, trunc int) string {
+	escape := func(s string) string {
+		if !autoescape {
+			return s
 		}
+		escaped, _ := filterEscape(AsValue(s), nil, nil)
+		return escaped.String()
+	}
 
-		return fmt.Sprintf(`<a href="mailto:%s">%s</a>`, mail, title)
-	})
+	var b bytes.Buffer
+	for _, word := range filterUrlizeWordRegexp.FindAllString(input, -1) {
+		lead, middle, trail := filterUrlizeSplitPunctuation(word)
+
+		var href string
+		lower := strings.ToLower(middle)
+		switch {
+		case strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://"):
+			iri, _ := filterIriencode(AsValue(middle), nil, nil)
+			href = iri.String()
+		case strings.HasPrefix(lower, "www.") || filterUrlizeDomainRegexp.MatchString(middle) && !strings.Contains(middle, "@"):
+			iri, _ := filterIriencode(AsValue(middle), nil, nil)
+			href = "http://" + iri.String()
+		case filterUrlizeEmailRegexp.MatchString(middle):
+			title := filterTruncatecharsHelper(middle, trunc)
+			fmt.Fprintf(&b, `%s<a href="mailto:%s">%s</a>%s`, escape(lead), escape(middle), escape(title), escape(trail))
+			continue
+		default:
+			b.WriteString(escape(word))
+			continue
+		}
 
-	return sout, nil
+		// The href is always escaped since it's an attribute value
+		escapedHref, _ := filterEscape(AsValue(href), nil, nil)
+		title := filterTruncatecharsHelper(middle, trunc)
+		fmt.Fprintf(&b, `%s<a href="%s" rel="nofollow">%s</a>%s`, escape(lead), escapedHref.String(), escape(title), escape(trail))
+	}
+	return b.String()
 }
 
+// filterUrlize converts URLs and email addresses within the text into links.
+// Trailing punctuation isn't made part of a link. The text is escaped unless
+// the argument is false; the result is marked safe.
 func filterUrlize(in *Value, param *Value, bind map[string]any) (*Value, *Error) {
 	autoescape := true
 	if param.IsBool() {
 		autoescape = param.Bool()
 	}
+	return AsSafeValue(filterUrlizeHelper(in.String(), autoescape, -1)), nil
+}
 
-	s, err := filterUrlizeHelper(in.String(), autoescape, -1)
-	if err != nil {
-		return nil, &Error{
-			Sender:    "filter:urlize",
-			OrigError: err,
+// filterUrlizetrunc works like urlize, but truncates the link texts to the
+// given number of characters (including an ellipsis).
+func filterUrlizetrunc(in *Value, param *Value, bind map[string]any) (*Value, *Error) {
+	return AsSafeValue(filterUrlizeHelper(in.String(), true, param.Integer())), nil
+}
+
+// filterStringformatVerb checks the format of stringformat: it must contain
+// exactly one verb (with optional flags, width and precision) besides any
+// literal "%%". The verb is returned.
+func filterStringformatVerb(format string) (byte, error) {
+	var verb byte
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			continue
+		}
+		i++
+		if i < len(format) && format[i] == '%' {
+			continue // literal percent sign
+		}
+		for i < len(format) && strings.IndexByte("+-# 0", format[i]) >= 0 {
+			i++ // flags
+		}
+		for i < len(format) && format[i] >= '0' && format[i] <= '9' {
+			i++ // width
 		}
+		if i < len(format) && format[i] == '.' {
+			i++ // precision
+			for i < len(format) && format[i] >= '0' && format[i] <= '9' {
+				i++
+			}
+		}
+		if i >= len(format) {
+			return 0, errors.New("missing verb at the end")
+		}
+		if strings.IndexByte("vTtbcdoOqxXUeEfFgGs", format[i]) < 0 {
+			return 0, fmt.Errorf("unsupported verb '%c'", format[i])
+		}
+		if verb != 0 {
+			return 0, errors.New("only one verb is allowed")
+		}
+		verb = format[i]
 	}
-
-	return AsValue(s), nil
+	if verb == 0 {
+		return 0, errors.New("missing verb")
+	}
+	return verb, nil
 }
 
-func filterUrlizetrunc(in *Value, param *Value, bind map[string]any) (*Value, *Error) {
-	s, err := filterUrlizeHelper(in.String(), true, param.Integer())
+// filterStringformat formats the input using Go's fmt.Sprintf. Like in
+// Django, an argument without a '%' is a single verb (including its flags,
+// width and precision) which gets a '%' prepended, e.g. "05.2f". The input is
+// converted to the type the verb expects if it isn't of that type already.
+func filterStringformat(in *Value, param *Value, bind map[string]any) (*Value, *Error) {
+	format := param.String()
+	if !strings.Contains(format, "%") && format != "" {
+		format = "%" + format
+	}
+
+	verb, err := filterStringformatVerb(format)
 	if err != nil {
 		return nil, &Error{
-			Sender:    "filter:urlizetrunc",
-			OrigError: err,
+			Sender:    "filter:stringformat",
+			OrigError: fmt.Errorf("invalid format '%s' for filter stringformat: %v", param.String(), err),
 		}
 	}
-	return AsValue(s), nil
-}
 
-func filterStringformat(in *Value, param *Value, bind map[string]any) (*Value, *Error) {
-	return AsValue(fmt.Sprintf(param.String(), in.Interface())), nil
+	arg := in.Interface()
+	switch verb {
+	case 'x', 'X':
+		if !in.IsInteger() && !in.IsString() {
+			arg = in.Integer()
+		}
+	case 'd', 'b', 'o', 'O', 'c', 'U':
+		if !in.IsInteger() {
+			arg = in.Integer()
+		}
+	case 'f', 'F', 'e', 'E', 'g', 'G':
+		if !in.IsFloat() {
+			arg = in.Float()
+		}
+	case 's':
+		if !in.IsString() {
+			arg = in.String()
+		}
+	case 't':
+		if !in.IsBool() {
+			arg = in.IsTrue()
+		}
+	case 'q':
+		if !in.IsString() && !in.IsInteger() {
+			arg = in.String()
+		}
+	}
+	return AsValue(fmt.Sprintf(format, arg)), nil
 }
 
 var reStriptags = regexp.MustCompile("<[^>]*?>")
@@ -805,6 +1963,94 @@ var filterPhone2numericMap = map[string]string{
 	"w": "9", "x": "9", "y": "9", "z": "9",
 }
 
+// filterNumberFormat formats a number with grouped thousands. Arguments are the
+// number of decimals (default: 0), the decimal point (default: ".") and the
+// thousands separator (default: ","). The separators can also be provided
+// by bind["decimal_point"] and bind["thousands_sep"].
+func filterNumberFormat(in *Value, param *Value, bind map[string]any) (*Value, *Error) {
+	decimals := 0
+	decimalPoint := "."
+	thousandsSep := ","
+
+	if dp, ok := bind["decimal_point"].(string); ok {
+		decimalPoint = dp
+	}
+	if ts, ok := bind["thousands_sep"].(string); ok {
+		thousandsSep = ts
+	}
+
+	args := FilterArguments(param)
+	if len(args) > 0 {
+		decimals = args[0].Integer()
+	}
+	if len(args) > 1 {
+		decimalPoint = args[1].String()
+	}
+	if len(args) > 2 {
+		thousandsSep = args[2].String()
+	}
+	if len(args) > 3 {
+		return nil, &Error{
+			Sender:    "filter:number_format",
+			OrigError: errors.New("you cannot pass more than 3 arguments to filter 'number_format'"),
+		}
+	}
+	if decimals < 0 || decimals > maxFloatFormatDecimals {
+		return nil, &Error{
+			Sender:    "filter:number_format",
+			OrigError: fmt.Errorf("filter number_format requires between 0 and %v decimals", maxFloatFormatDecimals),
+		}
+	}
+
+	// Format the absolute value without any grouping first; integers are being
+	// formatted directly to not lose any precision with very large values.
+	var formatted string
+	negative := false
+	rv := in.getResolvedValue()
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i := rv.Int()
+		u := uint64(i)
+		if i < 0 {
+			negative = true
+			u = -u
+		}
+		formatted = strconv.FormatUint(u, 10)
+		if decimals > 0 {
+			formatted += "." + strings.Repeat("0", decimals)
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		formatted = strconv.FormatUint(rv.Uint(), 10)
+		if decimals > 0 {
+			formatted += "." + strings.Repeat("0", decimals)
+		}
+	default:
+		f := in.Float()
+		formatted = strconv.FormatFloat(math.Abs(f), 'f', decimals, 64)
+		// Don't render a negative zero (e. g. "-0.00")
+		negative = f < 0 && strings.Trim(formatted, "0.") != ""
+	}
+
+	intPart, fracPart, _ := strings.Cut(formatted, ".")
+
+	var b strings.Builder
+	if negative {
+		b.WriteByte('-')
+	}
+	for i, c := range intPart {
+		if i > 0 && (len(intPart)-i)%3 == 0 {
+			b.WriteString(thousandsSep)
+		}
+		b.WriteRune(c)
+	}
+	if fracPart != "" {
+		b.WriteString(decimalPoint)
+		b.WriteString(fracPart)
+	}
+
+	return AsValue(b.String()), nil
+}
+
 func filterPhone2numeric(in *Value, param *Value, bind map[string]any) (*Value, *Error) {
 	sin := in.String()
 	for k, v := range filterPhone2numericMap {
@@ -814,54 +2060,246 @@ func filterPhone2numeric(in *Value, param *Value, bind map[string]any) (*Value,
 	return AsValue(sin), nil
 }
 
+func filterPluck(in *Value, param *Value, bind map[string]any) (*Value, *Error) {
+	if !in.CanSlice() {
+		return in, nil
+	}
+	attr := param.String()
+
+	values := make([]any, 0, in.Len())
+	for i := 0; i < in.Len(); i++ {
+		values = append(values, in.Index(i).getAttribute(attr).Interface())
+	}
+	return AsValue(values), nil
+}
+
+// filterPluralize returns a plural suffix ("s" or the argument) unless the
+// input is 1. The argument can be a "singular,plural" pair of suffixes or
+// words (like "y,ies" or "person,people"). The input is either a number, a
+// numeric string (like "2") or a collection (slice, array, map), whose length
+// is the count then. Other strings are an error.
 func filterPluralize(in *Value, param *Value, bind map[string]any) (*Value, *Error) {
-	if in.IsNumber() {
-		// Works only on numbers
-		if param.Len() > 0 {
-			endings := strings.Split(param.String(), ",")
-			if len(endings) > 2 {
-				return nil, &Error{
-					Sender:    "filter:pluralize",
-					OrigError: errors.New("you cannot pass more than 2 arguments to filter 'pluralize'"),
-				}
+	var plural bool
+	switch {
+	case in.IsNumber():
+		plural = in.Float() != 1
+	case in.IsString():
+		count, err := strconv.ParseFloat(in.String(), 64)
+		if err != nil {
+			return nil, &Error{
+				Sender:    "filter:pluralize",
+				OrigError: errors.New("filter 'pluralize' does only work on numbers, numeric strings and collections"),
 			}
-			if len(endings) == 1 {
-				// 1 argument
-				if in.Integer() != 1 {
-					return AsValue(endings[0]), nil
-				}
-			} else {
-				if in.Integer() != 1 {
-					// 2 arguments
-					return AsValue(endings[1]), nil
-				}
-				return AsValue(endings[0]), nil
+		}
+		plural = count != 1
+	case in.CanSlice() || in.getResolvedValue().Kind() == reflect.Map:
+		plural = in.Len() != 1
+	default:
+		return nil, &Error{
+			Sender:    "filter:pluralize",
+			OrigError: errors.New("filter 'pluralize' does only work on numbers, numeric strings and collections"),
+		}
+	}
+
+	singular, pluralSuffix := "", "s"
+	if param.Len() > 0 {
+		endings := strings.Split(param.String(), ",")
+		if len(endings) > 2 {
+			return nil, &Error{
+				Sender:    "filter:pluralize",
+				OrigError: errors.New("you cannot pass more than 2 arguments to filter 'pluralize'"),
 			}
+		}
+		if len(endings) == 1 {
+			// 1 argument
+			pluralSuffix = endings[0]
 		} else {
-			if in.Integer() != 1 {
-				// return default 's'
-				return AsValue("s"), nil
-			}
+			// 2 arguments
+			singular, pluralSuffix = endings[0], endings[1]
 		}
-
-		return AsValue(""), nil
 	}
-	return nil, &Error{
-		Sender:    "filter:pluralize",
-		OrigError: errors.New("filter 'pluralize' does only work on numbers"),
+
+	if plural {
+		return AsValue(pluralSuffix), nil
 	}
+	return AsValue(singular), nil
 }
 
+// filterRandom returns a random item of a slice or array (or a random
+// character of a string), nil if it's empty. The random source is taken from
+// bind["rand"] (a *rand.Rand) if given, so the choice can be made
+// deterministic by seeding it.
 func filterRandom(in *Value, param *Value, bind map[string]any) (*Value, *Error) {
-	if !in.CanSlice() || in.Len() <= 0 {
+	if !in.CanSlice() {
 		return in, nil
 	}
-	i := rand.Intn(in.Len())
-	return in.Index(i), nil
+	if in.Len() <= 0 {
+		return AsValue(nil), nil
+	}
+
+	return in.Index(filterRandomIntn(bind)(in.Len())), nil
+}
+
+// filterRandomIntn returns the Intn-function of bind["rand"] (a *rand.Rand)
+// if given, otherwise the one of the global random source.
+func filterRandomIntn(bind map[string]any) func(n int) int {
+	if r, ok := bind["rand"].(*rand.Rand); ok {
+		return r.Intn
+	}
+	return rand.Intn
+}
+
+const maxRangeLength = 100000
+
+// filterRange returns the integers from the input (inclusive) up to the first
+// argument (exclusive), like Python's range(). An optional second argument
+// is the step, which counts down if negative:
+//
+//	{% for i in 1|range:11 %}...{% endfor %}      (1, 2, ..., 10)
+//	{% for i in 10|range:0,step %}...{% endfor %} (10, 8, 6, 4, 2 with a step of -2)
+func filterRange(in *Value, param *Value, bind map[string]any) (*Value, *Error) {
+	args := FilterArguments(param)
+	if len(args) == 0 || len(args) > 2 || !in.IsInteger() || !args[0].IsInteger() || (len(args) == 2 && !args[1].IsInteger()) {
+		return nil, &Error{
+			Sender:    "filter:range",
+			OrigError: errors.New("filter range requires an integer input, an integer end and an optional integer step as arguments"),
+		}
+	}
+	start, end, step := in.Integer(), args[0].Integer(), 1
+	if len(args) == 2 {
+		step = args[1].Integer()
+	}
+	if step == 0 {
+		return nil, &Error{
+			Sender:    "filter:range",
+			OrigError: errors.New("filter range doesn't support a step of 0"),
+		}
+	}
+
+	// Computed unsigned since end - start overflows an int for extreme bounds
+	var count uint64
+	if step > 0 && end > start {
+		count = (uint64(end)-uint64(start)-1)/uint64(step) + 1
+	} else if step < 0 && end < start {
+		count = (uint64(start)-uint64(end)-1)/(0-uint64(step)) + 1
+	}
+	if count > maxRangeLength {
+		return nil, &Error{
+			Sender:    "filter:range",
+			OrigError: fmt.Errorf("filter range doesn't support more than %v items", maxRangeLength),
+		}
+	}
+
+	ints := make([]int, 0, count)
+	for i := 0; i < int(count); i++ {
+		ints = append(ints, start+i*step)
+	}
+	return AsValue(ints), nil
 }
 
 var reTag = regexp.MustCompile(`^[a-zA-Z]$`)
 
+// maxRegexReplaceCacheSize is the number of compiled patterns the
+// regex_replace filter keeps (patterns may come from template data).
+const maxRegexReplaceCacheSize = 100
+
+type filterRegexReplaceCacheEntry struct {
+	pattern string
+	re      *regexp.Regexp
+}
+
+// filterRegexReplaceCacheLRU holds the compiled patterns of the regex_replace
+// filter. If it's full, the least recently used pattern gets evicted.
+type filterRegexReplaceCacheLRU struct {
+	size int
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List // front = most recently used
+}
+
+func (c *filterRegexReplaceCacheLRU) get(pattern string) (*regexp.Regexp, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, has := c.entries[pattern]
+	if !has {
+		return nil, false
+	}
+	c.lru.MoveToFront(elem)
+	return elem.Value.(*filterRegexReplaceCacheEntry).re, true
+}
+
+func (c *filterRegexReplaceCacheLRU) add(pattern string, re *regexp.Regexp) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, has := c.entries[pattern]; has {
+		c.lru.MoveToFront(elem)
+		return
+	}
+
+	c.entries[pattern] = c.lru.PushFront(&filterRegexReplaceCacheEntry{pattern: pattern, re: re})
+	for c.lru.Len() > c.size {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*filterRegexReplaceCacheEntry).pattern)
+	}
+}
+
+var filterRegexReplaceCache = &filterRegexReplaceCacheLRU{
+	size:    maxRegexReplaceCacheSize,
+	entries: make(map[string]*list.Element),
+	lru:     list.New(),
+}
+
+// filterRegexReplaceSpec splits a "pattern/replacement" spec at the first
+// slash which isn't escaped by a backslash.
+func filterRegexReplaceSpec(spec string) (string, string, bool) {
+	for i := 0; i < len(spec); i++ {
+		switch spec[i] {
+		case '\\':
+			i++ // skip escaped char
+		case '/':
+			return spec[:i], spec[i+1:], true
+		}
+	}
+	return "", "", false
+}
+
+func filterRegexReplace(in *Value, param *Value, bind map[string]any) (*Value, *Error) {
+	var pattern, replacement string
+
+	if args := FilterArguments(param); len(args) == 2 {
+		// regex_replace:"pattern","replacement"
+		pattern, replacement = args[0].String(), args[1].String()
+	} else {
+		var ok bool
+		pattern, replacement, ok = filterRegexReplaceSpec(param.String())
+		if !ok {
+			return nil, &Error{
+				Sender:    "filter:regex_replace",
+				OrigError: fmt.Errorf("argument must be of the form \"pattern/replacement\" (got '%s')", param.String()),
+			}
+		}
+	}
+
+	re, has := filterRegexReplaceCache.get(pattern)
+	if !has {
+		var err error
+		re, err = regexp.Compile(pattern)
+		if err != nil {
+			return nil, &Error{
+				Sender:    "filter:regex_replace",
+				OrigError: fmt.Errorf("invalid pattern '%s': %v", pattern, err),
+			}
+		}
+		filterRegexReplaceCache.add(pattern, re)
+	}
+
+	return AsValue(re.ReplaceAllString(in.String(), replacement)), nil
+}
+
 func filterRemovetags(in *Value, param *Value, bind map[string]any) (*Value, *Error) {
 	s := in.String()
 	tags := strings.Split(param.String(), ",")
@@ -889,16 +2327,107 @@ func filterRemovetags(in *Value, param *Value, bind map[string]any) (*Value, *Er
 }
 
 func filterRjust(in *Value, param *Value, bind map[string]any) (*Value, *Error) {
-	padding := param.Integer()
+	padding, inWidth, wide, err := filterPaddingArguments("rjust", in, param)
+	if err != nil {
+		return nil, err
+	}
 	if padding > maxCharPadding {
 		return nil, &Error{
 			Sender:    "filter:rjust",
 			OrigError: fmt.Errorf("rjust doesn't support more padding than %c chars", maxCharPadding),
 		}
 	}
+	if wide {
+		if times := padding - inWidth; times > 0 {
+			return AsValue(strings.Repeat(" ", times) + in.String()), nil
+		}
+		return AsValue(in.String()), nil
+	}
 	return AsValue(fmt.Sprintf(fmt.Sprintf("%%%ds", padding), in.String())), nil
 }
 
+// filterSlugifyTransliterations maps (lower case) accented Latin letters to
+// their ASCII equivalents.
+var filterSlugifyTransliterations = func() map[rune]string {
+	m := make(map[rune]string)
+	for ascii, letters := range map[string]string{
+		"a": "àáâãäåāăą", "c": "çćĉċč", "d": "ďđð", "e": "èéêëēĕėęě",
+		"g": "ĝğġģ", "h": "ĥħ", "i": "ìíîïĩīĭįı", "j": "ĵ", "k": "ķ",
+		"l": "ĺļľŀł", "n": "ñńņňŉ", "o": "òóôõöøōŏő", "r": "ŕŗř",
+		"s": "śŝşšș", "t": "ţťŧț", "u": "ùúûüũūŭůűų", "w": "ŵ", "y": "ýÿŷ",
+		"z": "źżž", "ae": "æ", "oe": "œ", "ss": "ß", "th": "þ",
+	} {
+		for _, r := range letters {
+			m[r] = ascii
+		}
+	}
+	return m
+}()
+
+// filterSlugifyUnicode lowercases the input and joins all runs of letters and
+// digits by single hyphens. Accented Latin letters are transliterated to ASCII
+// ("Müller" becomes "muller") and all other characters (like emojis) are
+// dropped, unless the argument is true, which keeps all Unicode letters.
+func filterSlugifyUnicode(in *Value, param *Value, bind map[string]any) (*Value, *Error) {
+	keepUnicode := param.IsTrue()
+
+	var b strings.Builder
+	separate := false
+	for _, r := range strings.ToLower(in.String()) {
+		if unicode.Is(unicode.Mn, r) {
+			// Combining marks belong to the preceding letter
+			continue
+		}
+
+		var part string
+		switch {
+		case r < utf8.RuneSelf && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			part = string(r)
+		case keepUnicode && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			part = string(r)
+		case !keepUnicode:
+			part = filterSlugifyTransliterations[r]
+		}
+
+		if part == "" {
+			separate = b.Len() > 0
+			continue
+		}
+		if separate {
+			b.WriteByte('-')
+			separate = false
+		}
+		b.WriteString(part)
+	}
+	return AsValue(b.String()), nil
+}
+
+// filterShuffle returns the items of a slice or array in random order (as
+// a new slice). Like random, it takes the random source from bind["rand"].
+func filterShuffle(in *Value, param *Value, bind map[string]any) (*Value, *Error) {
+	switch in.getResolvedValue().Kind() {
+	case reflect.Array, reflect.Slice:
+	default:
+		return in, nil
+	}
+	if in.Len() <= 1 {
+		return in, nil
+	}
+
+	items := make([]any, 0, in.Len())
+	for i := 0; i < in.Len(); i++ {
+		items = append(items, in.Index(i).Interface())
+	}
+
+	// Fisher-Yates
+	intn := filterRandomIntn(bind)
+	for i := len(items) - 1; i > 0; i-- {
+		j := intn(i + 1)
+		items[i], items[j] = items[j], items[i]
+	}
+	return AsValue(items), nil
+}
+
 func filterSlice(in *Value, param *Value, bind map[string]any) (*Value, *Error) {
 	comp := strings.Split(param.String(), ":")
 	if len(comp) != 2 {
@@ -950,6 +2479,51 @@ func filterSlice(in *Value, param *Value, bind map[string]any) (*Value, *Error)
 	return in.Slice(from, to), nil
 }
 
+func filterSort(in *Value, param *Value, bind map[string]any) (*Value, *Error) {
+	// An optional attribute name to sort by; a leading "-" reverses the order
+	attr := param.String()
+	reverse := strings.HasPrefix(attr, "-")
+	attr = strings.TrimPrefix(attr, "-")
+
+	type sortItem struct {
+		item *Value
+		key  *Value
+	}
+	var items []sortItem
+
+	switch in.getResolvedValue().Kind() {
+	case reflect.Array, reflect.Slice:
+		for i := 0; i < in.Len(); i++ {
+			item := in.Index(i)
+			key := item
+			if attr != "" {
+				key = item.getAttribute(attr)
+			}
+			items = append(items, sortItem{item: item, key: key})
+		}
+	case reflect.Map:
+		// Maps are being sorted by their keys
+		for _, key := range in.getResolvedValue().MapKeys() {
+			items = append(items, sortItem{item: AsValue(key.Interface()), key: AsValue(key.Interface())})
+		}
+	default:
+		return in, nil
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		if reverse {
+			return valueLess(items[j].key, items[i].key)
+		}
+		return valueLess(items[i].key, items[j].key)
+	})
+
+	sorted := make([]any, 0, len(items))
+	for _, i := range items {
+		sorted = append(sorted, i.item.Interface())
+	}
+	return AsValue(sorted), nil
+}
+
 func filterTitle(in *Value, param *Value, bind map[string]any) (*Value, *Error) {
 	if !in.IsString() {
 		return AsValue(""), nil
@@ -957,27 +2531,80 @@ func filterTitle(in *Value, param *Value, bind map[string]any) (*Value, *Error)
 	return AsValue(strings.Title(strings.ToLower(in.String()))), nil
 }
 
+// filterWordcount counts the words of a text. Words are separated by Unicode
+// whitespace (including non-breaking spaces), while every Han character
+// (together with the combining marks following it) counts as a word of its
+// own since those scripts don't use spaces (punctuation directly following
+// such a character isn't counted). An optional mode forces one of the
+// behaviors: "spaces" only splits on whitespace, "chars" counts every other
+// character the way Han ones are counted.
 func filterWordcount(in *Value, param *Value, bind map[string]any) (*Value, *Error) {
-	return AsValue(len(strings.Fields(in.String()))), nil
+	mode := "auto"
+	if !param.IsNil() {
+		mode = param.String()
+	}
+
+	count := 0
+	inWord, afterChar := false, false
+	for _, r := range in.String() {
+		switch {
+		case unicode.IsSpace(r):
+			inWord, afterChar = false, false
+		case unicode.Is(unicode.M, r):
+			// Combining marks belong to the preceding character
+		case afterChar && unicode.IsPunct(r):
+			// Punctuation (like "。") following a counted character
+		case mode == "chars" || (mode != "spaces" && unicode.Is(unicode.Han, r)):
+			count++
+			inWord, afterChar = false, true
+		case !inWord:
+			count++
+			inWord, afterChar = true, false
+		}
+	}
+
+	switch mode {
+	case "auto", "spaces", "chars":
+		return AsValue(count), nil
+	}
+	return nil, &Error{
+		Sender:    "filter:wordcount",
+		OrigError: fmt.Errorf("unknown wordcount mode '%s' (must be one of auto, spaces or chars)", mode),
+	}
 }
 
+// filterWordwrap wraps the lines of a text at the given number of columns
+// (like Django). Lines are only broken between words, so words longer than
+// the width overflow; existing line breaks are kept.
 func filterWordwrap(in *Value, param *Value, bind map[string]any) (*Value, *Error) {
-	words := strings.Fields(in.String())
-	wordsLen := len(words)
-	wrapAt := param.Integer()
-	if wrapAt <= 0 {
+	width := param.Integer()
+	if width <= 0 {
 		return in, nil
 	}
 
-	linecount := wordsLen / wrapAt
-	if wordsLen%wrapAt > 0 {
-		linecount++
-	}
-	lines := make([]string, 0, linecount)
-	for i := 0; i < linecount; i++ {
-		lines = append(lines, strings.Join(words[wrapAt*i:min(wrapAt*(i+1), wordsLen)], " "))
+	lines := strings.Split(strings.ReplaceAll(in.String(), "\r\n", "\n"), "\n")
+	var b strings.Builder
+	for i, line := range lines {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		lineLen := 0
+		for _, word := range strings.Fields(line) {
+			wordLen := utf8.RuneCountInString(word)
+			if lineLen > 0 {
+				if lineLen+1+wordLen > width {
+					b.WriteByte('\n')
+					lineLen = 0
+				} else {
+					b.WriteByte(' ')
+					lineLen++
+				}
+			}
+			b.WriteString(word)
+			lineLen += wordLen
+		}
 	}
-	return AsValue(strings.Join(lines, "\n")), nil
+	return AsValue(b.String()), nil
 }
 
 func filterYesno(in *Value, param *Value, bind map[string]any) (*Value, *Error) {
@@ -1002,9 +2629,10 @@ func filterYesno(in *Value, param *Value, bind map[string]any) (*Value, *Error)
 			}
 		}
 
-		// Map to the options now
+		// Map to the options now; without a third option nil is a "no"
 		choices[0] = customChoices[0]
 		choices[1] = customChoices[1]
+		choices[2] = customChoices[1]
 		if len(customChoices) == 3 {
 			choices[2] = customChoices[2]
 		}
@@ -29,7 +29,7 @@ func FuzzBuiltinFilters(f *testing.F) {
 
 	f.Fuzz(func(t *testing.T, value, filterArg string) {
 		ts := NewSet("fuzz-test", &DummyLoader{})
-		for name := range filters {
+		for _, name := range ListFilters() {
 			tpl, err := ts.FromString(fmt.Sprintf("{{ %v|%v:%v }}", value, name, filterArg))
 			if tpl != nil && err != nil {
 				t.Errorf("filter=%q value=%q, filterArg=%q, err=%v", name, value, filterArg, err)
@@ -40,3 +40,27 @@ func FuzzBuiltinFilters(f *testing.F) {
 		}
 	})
 }
+
+func TestFilterRegexReplaceCacheBounded(t *testing.T) {
+	for i := 0; i < 3*maxRegexReplaceCacheSize; i++ {
+		out, err := ApplyFilter("regex_replace", AsValue("a1"), AsValue(fmt.Sprintf("[0-9]{1,%d}/x", i+1)), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out.String() != "ax" {
+			t.Fatalf("got %q, want %q", out.String(), "ax")
+		}
+	}
+
+	filterRegexReplaceCache.mu.Lock()
+	defer filterRegexReplaceCache.mu.Unlock()
+	if n := filterRegexReplaceCache.lru.Len(); n != maxRegexReplaceCacheSize {
+		t.Fatalf("cache holds %d patterns, want %d", n, maxRegexReplaceCacheSize)
+	}
+	if len(filterRegexReplaceCache.entries) != maxRegexReplaceCacheSize {
+		t.Fatalf("cache index holds %d patterns, want %d", len(filterRegexReplaceCache.entries), maxRegexReplaceCacheSize)
+	}
+	if _, has := filterRegexReplaceCache.entries[fmt.Sprintf("[0-9]{1,%d}", 3*maxRegexReplaceCacheSize)]; !has {
+		t.Fatal("most recently used pattern has been evicted")
+	}
+}
@@ -0,0 +1,666 @@
+package pongo2_test
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/flosch/pongo2/v6"
+)
+
+func identityFilter(in *pongo2.Value, param *pongo2.Value, bind map[string]any) (*pongo2.Value, *pongo2.Error) {
+	return in, nil
+}
+
+func TestListFilters(t *testing.T) {
+	if err := pongo2.RegisterFilter("zz_list_test_b", identityFilter); err != nil {
+		t.Fatal(err)
+	}
+	if err := pongo2.RegisterFilter("zz_list_test_a", identityFilter); err != nil {
+		t.Fatal(err)
+	}
+
+	names := pongo2.ListFilters()
+	if !sort.StringsAreSorted(names) {
+		t.Fatalf("ListFilters() is not sorted: %v", names)
+	}
+
+	idxA := sort.SearchStrings(names, "zz_list_test_a")
+	idxB := sort.SearchStrings(names, "zz_list_test_b")
+	if idxA >= len(names) || names[idxA] != "zz_list_test_a" {
+		t.Fatalf("zz_list_test_a not found in %v", names)
+	}
+	if idxB >= len(names) || names[idxB] != "zz_list_test_b" {
+		t.Fatalf("zz_list_test_b not found in %v", names)
+	}
+	if idxA+1 != idxB {
+		t.Fatalf("expected zz_list_test_a directly before zz_list_test_b, got indices %d and %d", idxA, idxB)
+	}
+
+	if len(pongo2.FilterNames()) != len(names) {
+		t.Fatalf("FilterNames() and ListFilters() differ")
+	}
+}
+
+func TestUnregisterFilter(t *testing.T) {
+	mustEqual(t, pongo2.UnregisterFilter("zz_unregister_test").Error(), ".*does not exist.*")
+
+	if err := pongo2.RegisterFilter("zz_unregister_test", identityFilter); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pongo2.FromString("{{ 1|zz_unregister_test }}"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := pongo2.UnregisterFilter("zz_unregister_test"); err != nil {
+		t.Fatal(err)
+	}
+	if pongo2.FilterExists("zz_unregister_test") {
+		t.Fatal("filter still exists after UnregisterFilter()")
+	}
+
+	_, err := pongo2.FromString("{{ 1|zz_unregister_test }}")
+	if err == nil {
+		t.Fatal("expected an error for an unregistered filter")
+	}
+	mustEqual(t, err.Error(), ".*Filter 'zz_unregister_test' does not exist.*")
+}
+
+func TestUnregisterFilterConcurrently(t *testing.T) {
+	if err := pongo2.RegisterFilter("zz_unregister_concurrently", identityFilter); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	var succeeded int32
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if pongo2.UnregisterFilter("zz_unregister_concurrently") == nil {
+				atomic.AddInt32(&succeeded, 1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if succeeded != 1 {
+		t.Fatalf("filter was unregistered %d times, expected exactly once", succeeded)
+	}
+}
+
+func TestMustApplyFilter(t *testing.T) {
+	v := pongo2.MustApplyFilter("upper", pongo2.AsValue("hello"), nil, nil)
+	mustEqual(t, v.String(), "^HELLO$")
+
+	mustPanicMatch(t, func() {
+		pongo2.MustApplyFilter("doesnotexist", pongo2.AsValue("hello"), nil, nil)
+	}, `\[Error \(where: applyfilter\)\] filter with name 'doesnotexist' not found`)
+}
+
+func replaceFilter(in *pongo2.Value, param *pongo2.Value, bind map[string]any) (*pongo2.Value, *pongo2.Error) {
+	args := pongo2.FilterArguments(param)
+	if len(args) != 2 {
+		return nil, &pongo2.Error{
+			Sender:    "filter:zz_replace",
+			OrigError: fmt.Errorf("expected 2 arguments, got %d", len(args)),
+		}
+	}
+	return pongo2.AsValue(strings.Replace(in.String(), args[0].String(), args[1].String(), -1)), nil
+}
+
+func TestFilterMultipleArguments(t *testing.T) {
+	if err := pongo2.RegisterFilter("zz_replace", replaceFilter); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		template string
+		want     string
+	}{
+		{`{{ "hello world"|zz_replace:"world","pongo2" }}`, "hello pongo2"},
+		{`{{ "hello world"|zz_replace:"o",sep|upper }}`, "HELL0 W0RLD"},
+		{`{% filter zz_replace:"a","b" %}banana{% endfilter %}`, "bbnbnb"},
+		{`{{ add(1|add:2, 3) }}`, "6"},
+		{`{{ add((1|add:2), 3) }}`, "6"},
+		{`{{ ["a"|default:"x", "b"]|join:"," }}`, "a,b"},
+	}
+
+	for _, test := range tests {
+		out, err := pongo2.RenderTemplateString(test.template, pongo2.Context{
+			"sep": "0",
+			"add": func(a, b int) int { return a + b },
+		})
+		if err != nil {
+			t.Fatalf("%s: %v", test.template, err)
+		}
+		if out != test.want {
+			t.Errorf("%s: got %q, want %q", test.template, out, test.want)
+		}
+	}
+
+	_, err := pongo2.RenderTemplateString(`{{ "hello"|zz_replace:"l" }}`, nil)
+	if err == nil {
+		t.Fatal("expected an error for a missing argument")
+	}
+	mustEqual(t, err.Error(), ".*expected 2 arguments, got 1")
+}
+
+func localeFilter(ctx *pongo2.ExecutionContext, in *pongo2.Value, param *pongo2.Value) (*pongo2.Value, *pongo2.Error) {
+	locale, ok := ctx.Private["locale"]
+	if !ok {
+		locale = ctx.Public["locale"]
+	}
+	return pongo2.AsValue(fmt.Sprintf("%s (%v)", in.String(), locale)), nil
+}
+
+func TestContextFilter(t *testing.T) {
+	if err := pongo2.RegisterContextFilter("zz_locale", localeFilter); err != nil {
+		t.Fatal(err)
+	}
+	mustEqual(t, pongo2.RegisterContextFilter("zz_locale", localeFilter).Error(), ".*already registered.*")
+	if !pongo2.FilterExists("zz_locale") {
+		t.Fatal("context filter not found by FilterExists()")
+	}
+
+	out, err := pongo2.RenderTemplateString(`{{ "a"|zz_locale }} {% set locale = "de" %}{{ "b"|zz_locale|upper }}`, pongo2.Context{"locale": "en"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mustEqual(t, out, `^a \(en\) B \(DE\)$`)
+
+	out, err = pongo2.RenderTemplateString(`{% filter zz_locale %}a{% endfilter %} {% set locale = "de" %}{% filter zz_locale|upper %}b{% endfilter %}`, pongo2.Context{"locale": "en"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mustEqual(t, out, `^a \(en\) B \(DE\)$`)
+
+	_, err = pongo2.ApplyFilter("zz_locale", pongo2.AsValue("a"), nil, nil)
+	if err == nil {
+		t.Fatal("expected an error when applying a context filter without a context")
+	}
+	mustEqual(t, err.Error(), ".*requires an execution context.*")
+}
+
+func TestDateFilterTimezoneFromBind(t *testing.T) {
+	instant := time.Date(2014, 6, 10, 15, 30, 15, 0, time.UTC)
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Skip(err)
+	}
+
+	tests := []struct {
+		tz   any
+		want string
+	}{
+		{tokyo, "2014-06-11 00:30 JST"},
+		{"Europe/Berlin", "2014-06-10 17:30 CEST"},
+	}
+	for _, test := range tests {
+		out, err := pongo2.RenderTemplateString(`{{ t|date:"2006-01-02 15:04 MST" }}`, pongo2.Context{"t": instant, "tz": test.tz})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out != test.want {
+			t.Errorf("tz %v: got %q, want %q", test.tz, out, test.want)
+		}
+	}
+
+	// An explicit argument takes precedence over bind["tz"]
+	out, err := pongo2.RenderTemplateString(`{{ t|date:"15:04","UTC" }}`, pongo2.Context{"t": instant, "tz": tokyo})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mustEqual(t, out, "^15:30$")
+}
+
+func TestTimesinceTimeuntilWithBindNow(t *testing.T) {
+	now := time.Date(2020, 1, 15, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		template string
+		t        time.Time
+		want     string
+	}{
+		{"{{ t|timesince }}", now.Add(-3 * time.Hour), "3 hours"},
+		{"{{ t|timesince }}", now.Add(-(2*24 + 4) * time.Hour), "2 days, 4 hours"},
+		{"{{ t|timesince }}", now.Add(-(24*time.Hour + 30*time.Minute)), "1 day"},
+		{"{{ t|timesince }}", now.Add(-61 * time.Minute), "1 hour, 1 minute"},
+		{"{{ t|timesince }}", now.Add(-30 * time.Second), "0 minutes"},
+		{"{{ t|timesince }}", now.Add(time.Hour), "0 minutes"},
+		{"{{ t|timeuntil }}", now.Add(15 * 24 * time.Hour), "2 weeks, 1 day"},
+		{"{{ t|timeuntil }}", now.Add(-time.Hour), "0 minutes"},
+	}
+
+	for _, test := range tests {
+		out, err := pongo2.RenderTemplateString(test.template, pongo2.Context{"t": test.t, "now": now})
+		if err != nil {
+			t.Fatalf("%s: %v", test.template, err)
+		}
+		if out != test.want {
+			t.Errorf("%s (t = %s): got %q, want %q", test.template, test.t, out, test.want)
+		}
+	}
+}
+
+func TestNumberFormatSeparatorsFromBind(t *testing.T) {
+	out, err := pongo2.RenderTemplateString(`{{ n|number_format:2 }} {{ i|number_format }} {{ n|number_format:1,"." }}`, pongo2.Context{
+		"n":             -1234567.891,
+		"i":             int64(-9223372036854775808),
+		"decimal_point": ",",
+		"thousands_sep": ".",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mustEqual(t, out, `^-1\.234\.567,89 -9\.223\.372\.036\.854\.775\.808 -1\.234\.567\.9$`)
+}
+
+func truncatedFlagFilter(in *pongo2.Value, param *pongo2.Value, bind map[string]any) (*pongo2.Value, *pongo2.Error) {
+	s := in.String()
+	n := param.Integer()
+	if n < 0 {
+		n = 0
+	}
+	bind["truncated"] = len(s) > n
+	if len(s) > n {
+		s = s[:n]
+	}
+	return pongo2.AsValue(s), nil
+}
+
+func TestFilterBindMutations(t *testing.T) {
+	if err := pongo2.RegisterFilter("zz_truncate_flag", truncatedFlagFilter); err != nil {
+		t.Fatal(err)
+	}
+
+	bind := map[string]any{}
+	v, err := pongo2.ApplyFilter("zz_truncate_flag", pongo2.AsValue("Hello World"), pongo2.AsValue(5), bind)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mustEqual(t, v.String(), "^Hello$")
+	if truncated, _ := bind["truncated"].(bool); !truncated {
+		t.Fatalf("bind mutation not visible to the caller: %v", bind)
+	}
+
+	// A nil bind must not cause any panic
+	if _, err := pongo2.ApplyFilter("zz_truncate_flag", pongo2.AsValue("Hello"), pongo2.AsValue(5), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// Within templates, the mutations are visible to the rest of the template
+	out, renderErr := pongo2.RenderTemplateString(
+		`{{ s|zz_truncate_flag:5 }}{% if truncated %}...{% endif %}|{% for i in items %}{{ i|zz_truncate_flag:3 }}{% if truncated %}*{% endif %} {% endfor %}`,
+		pongo2.Context{"s": "Hello World", "items": []string{"abc", "abcdef"}},
+	)
+	if renderErr != nil {
+		t.Fatal(renderErr)
+	}
+	mustEqual(t, out, `^Hello\.\.\.\|abc abc\* $`)
+}
+
+func panickingFilter(in *pongo2.Value, param *pongo2.Value, bind map[string]any) (*pongo2.Value, *pongo2.Error) {
+	panic("something went wrong")
+}
+
+func TestFilterPanicRecovery(t *testing.T) {
+	if err := pongo2.RegisterFilter("zz_panic", panickingFilter); err != nil {
+		t.Fatal(err)
+	}
+	defer pongo2.UnregisterFilter("zz_panic")
+
+	tpl, err := pongo2.FromString("{{ 1|zz_panic }}")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Disabled by default
+	mustPanicMatch(t, func() { _, _ = tpl.Execute(nil) }, "something went wrong")
+
+	pongo2.SetFilterPanicRecovery(true)
+	defer pongo2.SetFilterPanicRecovery(false)
+
+	_, err = tpl.Execute(nil)
+	if err == nil {
+		t.Fatal("expected an error from a panicking filter")
+	}
+	mustEqual(t, err.Error(), `\[Error \(where: filter:zz_panic\) in <string> \| Line 1 Col 6 near 'zz_panic'\] filter panicked: something went wrong`)
+
+	_, err = pongo2.RenderTemplateString("{% filter zz_panic %}abc{% endfilter %}", nil)
+	if err == nil {
+		t.Fatal("expected an error from a panicking filter within the filter tag")
+	}
+	mustEqual(t, err.Error(), ".*filter panicked: something went wrong")
+
+	_, applyErr := pongo2.ApplyFilter("zz_panic", pongo2.AsValue(1), nil, nil)
+	if applyErr == nil {
+		t.Fatal("expected an error from ApplyFilter")
+	}
+	mustEqual(t, applyErr.Error(), `\[Error \(where: filter:zz_panic\)\] filter panicked: something went wrong`)
+}
+
+func TestFilterPanicRecoveryPerSet(t *testing.T) {
+	if err := pongo2.RegisterFilter("zz_panic_set", panickingFilter); err != nil {
+		t.Fatal(err)
+	}
+	defer pongo2.UnregisterFilter("zz_panic_set")
+
+	set := pongo2.NewSet("panic recovery", pongo2.NewFSLoader(fstest.MapFS{}))
+	set.RecoverFilterPanics = true
+	tpl, err := set.FromString(`{{ 1|zz_panic_set }}{% filter zz_panic_set %}abc{% endfilter %}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = tpl.Execute(nil)
+	if err == nil {
+		t.Fatal("expected an error from a panicking filter")
+	}
+	mustEqual(t, err.Error(), ".*filter:zz_panic_set.*filter panicked: something went wrong")
+
+	// Other sets aren't affected
+	tpl, err = pongo2.FromString(`{{ 1|zz_panic_set }}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mustPanicMatch(t, func() { _, _ = tpl.Execute(nil) }, "something went wrong")
+}
+
+func TestRegisterFilters(t *testing.T) {
+	err := pongo2.RegisterFilters(map[string]pongo2.FilterFunction{
+		"zz_batch_a": identityFilter,
+		"upper":      identityFilter,
+		"zz_batch_b": identityFilter,
+	})
+	if err == nil {
+		t.Fatal("expected an error for an already registered filter")
+	}
+	mustEqual(t, err.Error(), "^filter with name 'upper' is already registered$")
+	if pongo2.FilterExists("zz_batch_a") || pongo2.FilterExists("zz_batch_b") {
+		t.Fatal("filters have been registered partially")
+	}
+
+	pongo2.MustRegisterFilters(map[string]pongo2.FilterFunction{
+		"zz_batch_a": identityFilter,
+		"zz_batch_b": identityFilter,
+	})
+	if !pongo2.FilterExists("zz_batch_a") || !pongo2.FilterExists("zz_batch_b") {
+		t.Fatal("filters have not been registered")
+	}
+
+	mustPanicMatch(t, func() {
+		pongo2.MustRegisterFilters(map[string]pongo2.FilterFunction{"zz_batch_a": identityFilter})
+	}, "filter with name 'zz_batch_a' is already registered")
+}
+
+func TestFilterErrorPosition(t *testing.T) {
+	pongo2.MustRegisterFilters(map[string]pongo2.FilterFunction{
+		"zz_failing": func(in *pongo2.Value, param *pongo2.Value, bind map[string]any) (*pongo2.Value, *pongo2.Error) {
+			return nil, &pongo2.Error{OrigError: errors.New("failed")}
+		},
+	})
+
+	set := pongo2.NewSet("filter errors", pongo2.NewFSLoader(fstest.MapFS{
+		"page.html":    {Data: []byte("<h1>{{ title }}</h1>\n<p>\n  {{ body|upper|zz_failing }}\n</p>")},
+		"wrapper.html": {Data: []byte("wrapped:\n{% include \"page.html\" %}")},
+	}))
+
+	for _, name := range []string{"page.html", "wrapper.html"} {
+		tpl, err := set.FromFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = tpl.Execute(nil)
+		var perr *pongo2.Error
+		if !errors.As(err, &perr) {
+			t.Fatalf("expected an *Error, got %T", err)
+		}
+		mustEqual(t, perr.Sender, "filter:zz_failing")
+		mustEqual(t, perr.Filename, "^/?page\\.html$")
+		mustEqual(t, fmt.Sprintf("%d:%d", perr.Line, perr.Column), "3:17")
+		mustEqual(t, perr.Error(), "^"+regexp.QuoteMeta("[Error (where: filter:zz_failing) in ")+"/?"+
+			regexp.QuoteMeta("page.html | Line 3 Col 17 near 'zz_failing'] failed")+"$")
+	}
+
+	// Without a template there's no position, but the filter is named
+	_, err := pongo2.ApplyFilter("zz_failing", pongo2.AsValue("x"), nil, nil)
+	mustEqual(t, err.Error(), regexp.QuoteMeta("[Error (where: filter:zz_failing)] failed"))
+}
+
+func TestFilterSharedError(t *testing.T) {
+	shared := &pongo2.Error{OrigError: errors.New("shared failure")}
+	pongo2.MustRegisterFilters(map[string]pongo2.FilterFunction{
+		"zz_shared_error": func(in *pongo2.Value, param *pongo2.Value, bind map[string]any) (*pongo2.Value, *pongo2.Error) {
+			return nil, shared
+		},
+	})
+
+	tpl, err := pongo2.FromString("{{ 1|zz_shared_error }}")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, 8)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = tpl.Execute(nil)
+		}(i)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		mustEqual(t, fmt.Sprintf("%v", err), ".*where: filter:zz_shared_error.*Line 1 Col 6.*shared failure")
+	}
+
+	if shared.Sender != "" || shared.Line != 0 {
+		t.Fatalf("the filter's error has been modified: %#v", shared)
+	}
+}
+
+func TestFilterGet(t *testing.T) {
+	type key string
+	set := pongo2.NewSet("filter get", pongo2.NewFSLoader(fstest.MapFS{}))
+	set.UndefinedBehavior = pongo2.UndefinedError
+
+	tpl, err := set.FromString(`{{ m|get:"a" }} {{ m|get:2 }} {{ m|get:"b"|default:"fallback" }} {{ named|get:"k" }} {{ s|get:"Name" }} {{ s|get:"age"|default:"unexported" }} {{ m|get:l|default:"unhashable" }} {{ named|get:l|default:"unhashable" }}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := tpl.Execute(pongo2.Context{
+		"m":     map[any]any{"a": 1, 2: "two"},
+		"l":     []int{1, 2},
+		"named": map[key]string{"k": "v"},
+		"s": struct {
+			Name string
+			age  int
+		}{"flosch", 42},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mustEqual(t, out, "^1 two fallback v flosch unexported unhashable unhashable$")
+}
+
+func TestFilterRangeExtremeBounds(t *testing.T) {
+	a, b := math.MinInt64+10, math.MaxInt64-10
+	ctx := pongo2.Context{"a": a, "b": b, "up": math.MaxInt64, "down": math.MinInt64, "back": -1}
+
+	_, err := pongo2.RenderTemplateString(`{{ a|range:b }}`, ctx)
+	if err == nil {
+		t.Fatal("expected an error for too many items")
+	}
+	mustEqual(t, err.Error(), ".*filter range doesn't support more than 100000 items.*")
+
+	_, err = pongo2.RenderTemplateString(`{{ b|range:a,back }}`, ctx)
+	if err == nil {
+		t.Fatal("expected an error for too many items")
+	}
+	mustEqual(t, err.Error(), ".*filter range doesn't support more than 100000 items.*")
+
+	out, err := pongo2.RenderTemplateString(`{{ a|range:b,up|join:"," }}|{{ b|range:a,down|join:"," }}`, ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mustEqual(t, out, fmt.Sprintf("^%d,%d,%d|%d,%d$", a, a+math.MaxInt64, b, b, b+math.MinInt64))
+}
+
+func TestFilterDictsort(t *testing.T) {
+	rows := []map[string]any{
+		{"lastname": "Miller", "age": 42},
+		{"lastname": "Adams", "age": 7.5},
+		{"lastname": "Smith"},
+		{"lastname": "Brown", "age": 19},
+	}
+	tpl, err := pongo2.FromString(`{% for r in rows|dictsort:"lastname" %}{{ r.lastname }} {% endfor %}|` +
+		`{% for r in rows|dictsortreversed:"lastname" %}{{ r.lastname }} {% endfor %}|` +
+		`{% for r in rows|dictsort:"age" %}{{ r.lastname }} {% endfor %}|` +
+		`{% for r in rows|dictsortreversed:"age" %}{{ r.lastname }} {% endfor %}|` +
+		`{% for p in people|dictsort:"Name" %}{{ p.Name }} {% endfor %}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := tpl.Execute(pongo2.Context{
+		"rows":   rows,
+		"people": []struct{ Name string }{{"b"}, {"c"}, {"a"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mustEqual(t, out, `^Adams Brown Miller Smith \|Smith Miller Brown Adams \|Smith Adams Brown Miller \|Miller Brown Adams Smith \|a b c $`)
+}
+
+func TestFilterWordwrapParagraphs(t *testing.T) {
+	v, err := pongo2.ApplyFilter("wordwrap",
+		pongo2.AsValue("short line\n\na second paragraph which is longer\r\nlast  one"), pongo2.AsValue(12), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "short line\n\na second\nparagraph\nwhich is\nlonger\nlast one"; v.String() != expected {
+		t.Fatalf("expected %q, got %q", expected, v.String())
+	}
+}
+
+func TestFilterNl2br(t *testing.T) {
+	out, err := pongo2.RenderTemplateString("{{ text|nl2br }}|{{ safetext|nl2br }}", pongo2.Context{
+		"text":     "<b>1</b>\r\n2\n",
+		"safetext": pongo2.AsSafeValue("<b>1</b>\n2"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "&lt;b&gt;1&lt;/b&gt;<br>\n2<br>\n|<b>1</b><br>\n2"; out != expected {
+		t.Fatalf("expected %q, got %q", expected, out)
+	}
+}
+
+func TestFilterEscapeOnceAndForceEscape(t *testing.T) {
+	out, err := pongo2.RenderTemplateString(`{{ text|escape_once }}|{{ text|escape_once|escape_once }}|{{ safetext }}|{{ safetext|force_escape }}`, pongo2.Context{
+		"text":     "Tom &amp; Jerry &#39;n&#x27; <friends> & co",
+		"safetext": pongo2.AsSafeValue("<b>&amp;</b>"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "Tom &amp; Jerry &#39;n&#x27; &lt;friends&gt; &amp; co|Tom &amp; Jerry &#39;n&#x27; &lt;friends&gt; &amp; co|<b>&amp;</b>|&lt;b&gt;&amp;amp;&lt;/b&gt;"; out != expected {
+		t.Fatalf("expected %q, got %q", expected, out)
+	}
+}
+
+func TestFilterUrlize(t *testing.T) {
+	tests := []struct {
+		tpl, expected string
+	}{
+		{
+			`{{ "See https://example.com/a?b=1&c=2, or <www.example.org>."|urlize }}`,
+			`See <a href="https://example.com/a?b=1&amp;c=2" rel="nofollow">https://example.com/a?b=1&amp;c=2</a>, or &lt;<a href="http://www.example.org" rel="nofollow">www.example.org</a>&gt;.`,
+		},
+		{
+			`{{ "(visit github.com/flosch/pongo2!) & \"example.de\""|urlize }}`,
+			`(visit <a href="http://github.com/flosch/pongo2" rel="nofollow">github.com/flosch/pongo2</a>!) &amp; &quot;<a href="http://example.de" rel="nofollow">example.de</a>&quot;`,
+		},
+		{
+			`{{ "Mail <me> at demo.user+tag@mail.example.com."|urlize }}`,
+			`Mail &lt;me&gt; at <a href="mailto:demo.user+tag@mail.example.com">demo.user+tag@mail.example.com</a>.`,
+		},
+		{
+			`{{ "Go to https://www.florian-schlachter.de/path now"|urlizetrunc:15 }}`,
+			`Go to <a href="https://www.florian-schlachter.de/path" rel="nofollow">https://www....</a> now`,
+		},
+		{
+			`{{ "<b>example.com</b>"|urlize:false }}`,
+			`<b>example.com</b>`,
+		},
+	}
+	for _, test := range tests {
+		out, err := pongo2.RenderTemplateString(test.tpl, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out != test.expected {
+			t.Errorf("%s: expected %q, got %q", test.tpl, test.expected, out)
+		}
+	}
+}
+
+func TestFilterRandomSeeded(t *testing.T) {
+	quotes := []string{"first", "second", "third", "fourth", "fifth"}
+	word := "pongo"
+
+	// The expected picks come from an identically seeded source
+	r := rand.New(rand.NewSource(42))
+	expected := fmt.Sprintf("%s|%s|%c|", quotes[r.Intn(len(quotes))], quotes[r.Intn(len(quotes))], []rune(word)[r.Intn(len(word))])
+
+	out, err := pongo2.RenderTemplateString(`{{ quotes|random }}|{{ quotes|random }}|{{ word|random }}|{{ empty|random|default_if_none:"" }}`, pongo2.Context{
+		"rand":   rand.New(rand.NewSource(42)),
+		"quotes": quotes,
+		"word":   word,
+		"empty":  []int{},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != expected {
+		t.Fatalf("expected %q, got %q", expected, out)
+	}
+
+	v, filterErr := pongo2.ApplyFilter("random", pongo2.AsValue([]int{}), nil, nil)
+	if filterErr != nil {
+		t.Fatal(filterErr)
+	}
+	if !v.IsNil() {
+		t.Errorf("expected nil for an empty slice, got %v", v)
+	}
+}
+
+func TestFilterShuffleSeeded(t *testing.T) {
+	answers := []string{"a", "b", "c", "d", "e"}
+	ctx := pongo2.Context{
+		"rand":    rand.New(rand.NewSource(42)),
+		"answers": answers,
+		"one":     []int{1},
+		"none":    []int{},
+	}
+
+	out, err := pongo2.RenderTemplateString(`{{ answers|shuffle|join:"," }}|{{ answers|join:"," }}|{{ one|shuffle|join:"," }}|{{ none|shuffle|length }}|{{ "abc"|shuffle }}`, ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "b,e,c,d,a|a,b,c,d,e|1|0|abc"; out != expected {
+		t.Fatalf("expected %q, got %q", expected, out)
+	}
+	if strings.Join(answers, ",") != "a,b,c,d,e" {
+		t.Errorf("the input has been modified: %v", answers)
+	}
+}
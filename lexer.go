@@ -3,6 +3,8 @@ package pongo2
 import (
 	"errors"
 	"fmt"
+	"regexp"
+	"sort"
 	"strings"
 	"unicode/utf8"
 )
@@ -27,22 +29,78 @@ var (
 	tokenIdentifierCharsWithDigits = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ_0123456789"
 	tokenDigits                    = "0123456789"
 
-	// Available symbols in pongo2 (within filters/tag)
+	// Available symbols in pongo2 (within filters/tag); the delimiters of
+	// variables and tags are matched before (see delimiters)
 	TokenSymbols = []string{
 		// 3-Char symbols
 		"{{-", "-}}", "{%-", "-%}",
 
 		// 2-Char symbols
-		"==", ">=", "<=", "&&", "||", "{{", "}}", "{%", "%}", "!=", "<>",
+		"==", ">=", "<=", "&&", "||", "{{", "}}", "{%", "%}", "!=", "<>", "??",
 
 		// 1-Char symbol
-		"(", ")", "+", "-", "*", "<", ">", "/", "^", ",", ".", "!", "|", ":", "=", "%", "[", "]",
+		"(", ")", "+", "-", "*", "<", ">", "/", "^", ",", ".", "!", "|", ":", "=", "%", "[", "]", "?",
 	}
 
 	// Available keywords in pongo2
 	TokenKeywords = []string{"in", "and", "or", "not", "true", "false", "as", "export"}
+
+	defaultDelimiters = newDelimiters("{{", "}}", "{%", "%}", "{#", "#}")
 )
 
+// delimiters are the delimiters of variables, tags and comments which can be
+// configured per TemplateSet. Regardless of the configuration the lexer emits
+// them as the default symbols ("{{", "}}", "{%" and "%}") to the parser.
+type delimiters struct {
+	varStart, varEnd         string
+	tagStart, tagEnd         string
+	commentStart, commentEnd string
+
+	symbols       []delimiterSymbol // longest first
+	verbatimStart *regexp.Regexp    // start of a (optionally named) verbatim-tag
+}
+
+type delimiterSymbol struct {
+	delimiter string
+	symbol    string
+	trim      bool // whitespace control ({{- and -}})
+}
+
+func newDelimiters(varStart, varEnd, tagStart, tagEnd, commentStart, commentEnd string) *delimiters {
+	d := &delimiters{
+		varStart:     varStart,
+		varEnd:       varEnd,
+		tagStart:     tagStart,
+		tagEnd:       tagEnd,
+		commentStart: commentStart,
+		commentEnd:   commentEnd,
+		symbols: []delimiterSymbol{
+			{varStart + "-", "{{", true},
+			{"-" + varEnd, "}}", true},
+			{tagStart + "-", "{%", true},
+			{"-" + tagEnd, "%}", true},
+			{varStart, "{{", false},
+			{varEnd, "}}", false},
+			{tagStart, "{%", false},
+			{tagEnd, "%}", false},
+		},
+		verbatimStart: regexp.MustCompile(`^` + regexp.QuoteMeta(tagStart) + ` verbatim(?: ([a-zA-Z0-9_]+))? ` + regexp.QuoteMeta(tagEnd)),
+	}
+	sort.SliceStable(d.symbols, func(i, j int) bool {
+		return len(d.symbols[i].delimiter) > len(d.symbols[j].delimiter)
+	})
+	return d
+}
+
+// isDelimiterSymbol returns true if sym is one of the symbols emitted for delimiters.
+func isDelimiterSymbol(sym string) bool {
+	switch sym {
+	case "{{-", "-}}", "{%-", "-%}", "{{", "}}", "{%", "%}":
+		return true
+	}
+	return false
+}
+
 type (
 	TokenType int
 	Token     struct {
@@ -72,6 +130,9 @@ type (
 
 		inVerbatim   bool
 		verbatimName string
+		verbatimEnd  string // the endverbatim-tag closing the current verbatim block
+
+		delims *delimiters
 	}
 )
 
@@ -107,10 +168,11 @@ func (t *Token) String() string {
 		typ, t.Typ, val, t.Line, t.Col, t.TrimWhitespaces)
 }
 
-func lex(name string, input string) ([]*Token, *Error) {
+func lex(name string, input string, delims *delimiters) ([]*Token, *Error) {
 	l := &lexer{
 		name:      name,
 		input:     input,
+		delims:    delims,
 		tokens:    make([]*Token, 0, 100),
 		line:      1,
 		col:       1,
@@ -154,17 +216,22 @@ func (l *lexer) emit(t TokenType) {
 		tok.Val = strings.Replace(tok.Val, `\\`, `\`, -1)
 	}
 
-	if t == TokenSymbol && len(tok.Val) == 3 && (strings.HasSuffix(tok.Val, "-") || strings.HasPrefix(tok.Val, "-")) {
-		tok.TrimWhitespaces = true
-		tok.Val = strings.Replace(tok.Val, "-", "", -1)
-	}
-
 	l.tokens = append(l.tokens, tok)
 	l.start = l.pos
 	l.startline = l.line
 	l.startcol = l.col
 }
 
+// emitDelimiter emits a delimiter (of the given width) as its default symbol.
+func (l *lexer) emitDelimiter(width int, d delimiterSymbol) {
+	l.pos += width
+	l.col += width
+	l.emit(TokenSymbol)
+	tok := l.tokens[len(l.tokens)-1]
+	tok.Val = d.symbol
+	tok.TrimWhitespaces = d.trim
+}
+
 func (l *lexer) next() rune {
 	if l.pos >= len(l.input) {
 		l.width = 0
@@ -225,43 +292,55 @@ func (l *lexer) errorf(format string, args ...any) lexerStateFn {
 
 func (l *lexer) run() {
 	for {
-		// TODO: Support verbatim tag names
+		// Verbatim blocks can be named (like {% verbatim myblock %}) and are
+		// closed only by an endverbatim of the same name then.
 		// https://docs.djangoproject.com/en/dev/ref/templates/builtins/#verbatim
+		// The regular expression is only tried at a tag's start (it's too
+		// expensive to be run on every character)
+		atTagStart := strings.HasPrefix(l.input[l.pos:], l.delims.tagStart)
 		if l.inVerbatim {
-			name := l.verbatimName
-			if name != "" {
-				name += " "
-			}
-			if strings.HasPrefix(l.input[l.pos:], fmt.Sprintf("{%% endverbatim %s%%}", name)) { // end verbatim
+			if atTagStart && strings.HasPrefix(l.input[l.pos:], l.verbatimEnd) { // end verbatim
 				if l.pos > l.start {
 					l.emit(TokenHTML)
 				}
-				w := len("{% endverbatim %}")
+				w := len(l.verbatimEnd)
 				l.pos += w
 				l.col += w
 				l.ignore()
 				l.inVerbatim = false
+				l.verbatimName = ""
+				l.verbatimEnd = ""
+			} else if atTagStart && l.verbatimName == "" && l.delims.verbatimStart.MatchString(l.input[l.pos:]) {
+				l.errorf("Nested verbatim-tags are not allowed (use a named verbatim-tag to output a verbatim-tag).")
+				return
 			}
-		} else if strings.HasPrefix(l.input[l.pos:], "{% verbatim %}") { // tag
+		} else if m := l.verbatimStartMatch(atTagStart); m != nil { // tag
 			if l.pos > l.start {
 				l.emit(TokenHTML)
 			}
 			l.inVerbatim = true
-			w := len("{% verbatim %}")
+			l.verbatimName = m[1]
+			name := l.verbatimName
+			if name != "" {
+				name += " "
+			}
+			l.verbatimEnd = fmt.Sprintf("%s endverbatim %s%s", l.delims.tagStart, name, l.delims.tagEnd)
+			w := len(m[0])
 			l.pos += w
 			l.col += w
 			l.ignore()
+			continue // the verbatim block might be empty or start with a nested verbatim-tag
 		}
 
 		if !l.inVerbatim {
 			// Ignore single-line comments {# ... #}
-			if strings.HasPrefix(l.input[l.pos:], "{#") {
+			if strings.HasPrefix(l.input[l.pos:], l.delims.commentStart) {
 				if l.pos > l.start {
 					l.emit(TokenHTML)
 				}
 
-				l.pos += 2 // pass '{#'
-				l.col += 2
+				l.pos += len(l.delims.commentStart) // pass '{#'
+				l.col += len(l.delims.commentStart)
 
 				for {
 					switch l.peek() {
@@ -273,9 +352,9 @@ func (l *lexer) run() {
 						return
 					}
 
-					if strings.HasPrefix(l.input[l.pos:], "#}") {
-						l.pos += 2 // pass '#}'
-						l.col += 2
+					if strings.HasPrefix(l.input[l.pos:], l.delims.commentEnd) {
+						l.pos += len(l.delims.commentEnd) // pass '#}'
+						l.col += len(l.delims.commentEnd)
 						break
 					}
 
@@ -287,8 +366,8 @@ func (l *lexer) run() {
 				continue // next token
 			}
 
-			if strings.HasPrefix(l.input[l.pos:], "{{") || // variable
-				strings.HasPrefix(l.input[l.pos:], "{%") { // tag
+			if strings.HasPrefix(l.input[l.pos:], l.delims.varStart) || // variable
+				strings.HasPrefix(l.input[l.pos:], l.delims.tagStart) { // tag
 				if l.pos > l.start {
 					l.emit(TokenHTML)
 				}
@@ -319,6 +398,15 @@ func (l *lexer) run() {
 	}
 }
 
+// verbatimStartMatch returns the submatches of a verbatim-tag starting at the
+// current position (nil if there's none).
+func (l *lexer) verbatimStartMatch(atTagStart bool) []string {
+	if !atTagStart {
+		return nil
+	}
+	return l.delims.verbatimStart.FindStringSubmatch(l.input[l.pos:])
+}
+
 func (l *lexer) tokenize() {
 	for state := l.stateCode; state != nil; {
 		state = state()
@@ -343,18 +431,30 @@ outer_loop:
 			return l.stateString
 		}
 
+		// Check for delimiters
+		for _, d := range l.delims.symbols {
+			if strings.HasPrefix(l.input[l.start:], d.delimiter) {
+				l.emitDelimiter(len(d.delimiter), d)
+
+				if d.symbol == "%}" || d.symbol == "}}" {
+					// Tag/variable end, return after emit
+					return nil
+				}
+
+				continue outer_loop
+			}
+		}
+
 		// Check for symbol
 		for _, sym := range TokenSymbols {
+			if isDelimiterSymbol(sym) {
+				continue
+			}
 			if strings.HasPrefix(l.input[l.start:], sym) {
 				l.pos += len(sym)
 				l.col += l.length()
 				l.emit(TokenSymbol)
 
-				if sym == "%}" || sym == "-%}" || sym == "}}" || sym == "-}}" {
-					// Tag/variable end, return after emit
-					return nil
-				}
-
 				continue outer_loop
 			}
 		}
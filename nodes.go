@@ -7,10 +7,16 @@ type nodeDocument struct {
 
 func (doc *nodeDocument) Execute(ctx *ExecutionContext, writer TemplateWriter) *Error {
 	for _, n := range doc.Nodes {
+		if err := ctx.checkCancellation(); err != nil {
+			return err
+		}
 		err := n.Execute(ctx, writer)
 		if err != nil {
 			return err
 		}
+		if err := checkWriter(ctx, writer); err != nil {
+			return err
+		}
 	}
 	return nil
 }
@@ -7,10 +7,16 @@ type NodeWrapper struct {
 
 func (wrapper *NodeWrapper) Execute(ctx *ExecutionContext, writer TemplateWriter) *Error {
 	for _, n := range wrapper.nodes {
+		if err := ctx.checkCancellation(); err != nil {
+			return err
+		}
 		err := n.Execute(ctx, writer)
 		if err != nil {
 			return err
 		}
+		if err := checkWriter(ctx, writer); err != nil {
+			return err
+		}
 	}
 	return nil
 }
@@ -32,6 +32,14 @@ type Parser struct {
 	tokens    []*Token
 	lastToken *Token
 
+	// greater than zero while parsing a comma-separated argument list
+	// (function calls, array literals, macro arguments)
+	argumentListDepth int
+
+	// greater than zero while parsing the body of a for-loop (needed
+	// to validate the break- and continue-tags)
+	loopDepth int
+
 	// if the parser parses a template document, here will be
 	// a reference to it (needed to access the template through Tags)
 	template *Template
@@ -254,8 +262,12 @@ func (p *Parser) WrapUntilTag(names ...string) (*NodeWrapper, *Parser, *Error) {
 		}
 
 		// Otherwise process next element to be wrapped
+		startIdx := p.idx
 		node, err := p.parseDocElement()
 		if err != nil {
+			if p.recoverFrom(err, startIdx) {
+				continue
+			}
 			return nil, nil, err
 		}
 		wrapper.nodes = append(wrapper.nodes, node)
@@ -265,6 +277,57 @@ func (p *Parser) WrapUntilTag(names ...string) (*NodeWrapper, *Parser, *Error) {
 		p.lastToken)
 }
 
+// recoverFrom records err and skips all tokens up to the end of the erroneous
+// tag or variable (which started at startIdx), if the template set collects
+// all parse errors. Returns false if the error must be returned instead.
+func (p *Parser) recoverFrom(err *Error, startIdx int) bool {
+	if p.template == nil || !p.template.set.CollectParseErrors {
+		return false
+	}
+
+	// A tag failing before wrapping its content leaves its end-tag behind;
+	// don't report it as another error
+	var tagName string
+	if p.Get(startIdx) != nil && p.Get(startIdx).Typ == TokenSymbol && p.Get(startIdx).Val == "{%" {
+		if t := p.Get(startIdx + 1); t != nil && t.Typ == TokenIdentifier {
+			tagName = t.Val
+		}
+	}
+	isTag := TagExists(tagName)
+	if !isTag && p.template.orphanEndTags[tagName] > 0 {
+		p.template.orphanEndTags[tagName]--
+	} else if list, ok := err.OrigError.(ErrorList); ok {
+		// Errors of another template loaded while parsing (e. g. the parent)
+		p.template.parseErrors = append(p.template.parseErrors, list...)
+	} else {
+		p.template.parseErrors = append(p.template.parseErrors, err)
+	}
+	if isTag {
+		if p.template.orphanEndTags == nil {
+			p.template.orphanEndTags = make(map[string]int)
+		}
+		p.template.orphanEndTags["end"+tagName]++
+	}
+
+	// Always make progress
+	if p.idx <= startIdx {
+		p.idx = startIdx + 1
+	}
+
+	// Re-synchronize at the next tag/variable boundary
+	for p.Remaining() > 0 {
+		if prev := p.Get(p.idx - 1); prev != nil && prev.Typ == TokenSymbol && (prev.Val == "%}" || prev.Val == "}}") {
+			break
+		}
+		if p.PeekOne(TokenSymbol, "{%", "{{") != nil || p.PeekType(TokenHTML) != nil {
+			break
+		}
+		p.Consume()
+	}
+
+	return true
+}
+
 // Skips all nodes between starting tag and "{% endtag %}"
 func (p *Parser) SkipUntilTag(names ...string) *Error {
 	for p.Remaining() > 0 {
@@ -48,8 +48,12 @@ func (p *Parser) parseDocument() (*nodeDocument, *Error) {
 	doc := &nodeDocument{}
 
 	for p.Remaining() > 0 {
+		startIdx := p.idx
 		node, err := p.parseDocElement()
 		if err != nil {
+			if p.recoverFrom(err, startIdx) {
+				continue
+			}
 			return nil, err
 		}
 		doc.Nodes = append(doc.Nodes, node)
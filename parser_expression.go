@@ -12,6 +12,21 @@ type Expression struct {
 	opToken *Token
 }
 
+// conditionalExpression is the ternary operator: condition ? then : otherwise
+type conditionalExpression struct {
+	condition IEvaluator
+	then      IEvaluator
+	otherwise IEvaluator
+	opToken   *Token
+}
+
+// coalesceExpression returns the first non-nil operand: expr1 ?? expr2
+type coalesceExpression struct {
+	expr1   IEvaluator
+	expr2   IEvaluator
+	opToken *Token
+}
+
 type relationalExpression struct {
 	// TODO: Add location token?
 	expr1   IEvaluator
@@ -45,6 +60,14 @@ func (expr *Expression) FilterApplied(name string) bool {
 		(expr.expr2 != nil && expr.expr2.FilterApplied(name)))
 }
 
+func (expr *conditionalExpression) FilterApplied(name string) bool {
+	return expr.then.FilterApplied(name) && expr.otherwise.FilterApplied(name)
+}
+
+func (expr *coalesceExpression) FilterApplied(name string) bool {
+	return expr.expr1.FilterApplied(name) && expr.expr2.FilterApplied(name)
+}
+
 func (expr *relationalExpression) FilterApplied(name string) bool {
 	return expr.expr1.FilterApplied(name) && (expr.expr2 == nil ||
 		(expr.expr2 != nil && expr.expr2.FilterApplied(name)))
@@ -69,6 +92,14 @@ func (expr *Expression) GetPositionToken() *Token {
 	return expr.expr1.GetPositionToken()
 }
 
+func (expr *conditionalExpression) GetPositionToken() *Token {
+	return expr.condition.GetPositionToken()
+}
+
+func (expr *coalesceExpression) GetPositionToken() *Token {
+	return expr.expr1.GetPositionToken()
+}
+
 func (expr *relationalExpression) GetPositionToken() *Token {
 	return expr.expr1.GetPositionToken()
 }
@@ -94,6 +125,24 @@ func (expr *Expression) Execute(ctx *ExecutionContext, writer TemplateWriter) *E
 	return nil
 }
 
+func (expr *conditionalExpression) Execute(ctx *ExecutionContext, writer TemplateWriter) *Error {
+	value, err := expr.Evaluate(ctx)
+	if err != nil {
+		return err
+	}
+	writer.WriteString(value.String())
+	return nil
+}
+
+func (expr *coalesceExpression) Execute(ctx *ExecutionContext, writer TemplateWriter) *Error {
+	value, err := expr.Evaluate(ctx)
+	if err != nil {
+		return err
+	}
+	writer.WriteString(value.String())
+	return nil
+}
+
 func (expr *relationalExpression) Execute(ctx *ExecutionContext, writer TemplateWriter) *Error {
 	value, err := expr.Evaluate(ctx)
 	if err != nil {
@@ -165,6 +214,30 @@ func (expr *Expression) Evaluate(ctx *ExecutionContext) (*Value, *Error) {
 	}
 }
 
+func (expr *conditionalExpression) Evaluate(ctx *ExecutionContext) (*Value, *Error) {
+	cond, err := expr.condition.Evaluate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// Only the chosen branch is evaluated
+	if cond.IsTrue() {
+		return expr.then.Evaluate(ctx)
+	}
+	return expr.otherwise.Evaluate(ctx)
+}
+
+func (expr *coalesceExpression) Evaluate(ctx *ExecutionContext) (*Value, *Error) {
+	v1, err := expr.expr1.Evaluate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// Unlike or/|| this checks for nil only (so "", 0 and false are kept)
+	if !v1.IsNil() {
+		return v1, nil
+	}
+	return expr.expr2.Evaluate(ctx)
+}
+
 func (expr *relationalExpression) Evaluate(ctx *ExecutionContext) (*Value, *Error) {
 	v1, err := expr.expr1.Evaluate(ctx)
 	if err != nil {
@@ -177,6 +250,9 @@ func (expr *relationalExpression) Evaluate(ctx *ExecutionContext) (*Value, *Erro
 		}
 		switch expr.opToken.Val {
 		case "<=":
+			if isBigArithmetic(v1, v2) {
+				return AsValue(bigCompare(v1, v2) <= 0), nil
+			}
 			if v1.IsFloat() || v2.IsFloat() {
 				return AsValue(v1.Float() <= v2.Float()), nil
 			}
@@ -186,6 +262,9 @@ func (expr *relationalExpression) Evaluate(ctx *ExecutionContext) (*Value, *Erro
 			}
 			return AsValue(v1.Integer() <= v2.Integer()), nil
 		case ">=":
+			if isBigArithmetic(v1, v2) {
+				return AsValue(bigCompare(v1, v2) >= 0), nil
+			}
 			if v1.IsFloat() || v2.IsFloat() {
 				return AsValue(v1.Float() >= v2.Float()), nil
 			}
@@ -197,6 +276,9 @@ func (expr *relationalExpression) Evaluate(ctx *ExecutionContext) (*Value, *Erro
 		case "==":
 			return AsValue(v1.EqualValueTo(v2)), nil
 		case ">":
+			if isBigArithmetic(v1, v2) {
+				return AsValue(bigCompare(v1, v2) > 0), nil
+			}
 			if v1.IsFloat() || v2.IsFloat() {
 				return AsValue(v1.Float() > v2.Float()), nil
 			}
@@ -205,6 +287,9 @@ func (expr *relationalExpression) Evaluate(ctx *ExecutionContext) (*Value, *Erro
 			}
 			return AsValue(v1.Integer() > v2.Integer()), nil
 		case "<":
+			if isBigArithmetic(v1, v2) {
+				return AsValue(bigCompare(v1, v2) < 0), nil
+			}
 			if v1.IsFloat() || v2.IsFloat() {
 				return AsValue(v1.Float() < v2.Float()), nil
 			}
@@ -238,6 +323,12 @@ func (expr *simpleExpression) Evaluate(ctx *ExecutionContext) (*Value, *Error) {
 	if expr.negativeSign {
 		if result.IsNumber() {
 			switch {
+			case result.IsBigNumber():
+				negated, err := bigArithmetic("-", AsValue(0), result)
+				if err != nil {
+					return nil, ctx.OrigError(err, expr.GetPositionToken())
+				}
+				result = negated
 			case result.IsFloat():
 				result = AsValue(-1 * result.Float())
 			case result.IsInteger():
@@ -255,6 +346,13 @@ func (expr *simpleExpression) Evaluate(ctx *ExecutionContext) (*Value, *Error) {
 		if err != nil {
 			return nil, err
 		}
+		if isBigArithmetic(result, t2) {
+			value, err := bigArithmetic(expr.opToken.Val, result, t2)
+			if err != nil {
+				return nil, ctx.OrigError(err, expr.GetPositionToken())
+			}
+			return value, nil
+		}
 		switch expr.opToken.Val {
 		case "+":
 			if result.IsString() || t2.IsString() {
@@ -292,6 +390,13 @@ func (expr *term) Evaluate(ctx *ExecutionContext) (*Value, *Error) {
 		if err != nil {
 			return nil, err
 		}
+		if isBigArithmetic(f1, f2) {
+			value, err := bigArithmetic(expr.opToken.Val, f1, f2)
+			if err != nil {
+				return nil, ctx.OrigError(err, expr.factor2.GetPositionToken())
+			}
+			return value, nil
+		}
 		switch expr.opToken.Val {
 		case "*":
 			if f1.IsFloat() || f2.IsFloat() {
@@ -347,7 +452,11 @@ func (expr *power) Evaluate(ctx *ExecutionContext) (*Value, *Error) {
 
 func (p *Parser) parseFactor() (IEvaluator, *Error) {
 	if p.Match(TokenSymbol, "(") != nil {
+		// Commas within brackets can't belong to an outer argument list
+		argumentListDepth := p.argumentListDepth
+		p.argumentListDepth = 0
 		expr, err := p.ParseExpression()
+		p.argumentListDepth = argumentListDepth
 		if err != nil {
 			return nil, err
 		}
@@ -503,7 +612,7 @@ func (p *Parser) parseRelationalExpression() (IEvaluator, *Error) {
 	return expr, nil
 }
 
-func (p *Parser) ParseExpression() (IEvaluator, *Error) {
+func (p *Parser) parseLogicalExpression() (IEvaluator, *Error) {
 	rexpr1, err := p.parseRelationalExpression()
 	if err != nil {
 		return nil, err
@@ -516,7 +625,7 @@ func (p *Parser) ParseExpression() (IEvaluator, *Error) {
 	if p.PeekOne(TokenSymbol, "&&", "||") != nil || p.PeekOne(TokenKeyword, "and", "or") != nil {
 		op := p.Current()
 		p.Consume()
-		expr2, err := p.ParseExpression()
+		expr2, err := p.parseLogicalExpression()
 		if err != nil {
 			return nil, err
 		}
@@ -531,3 +640,67 @@ func (p *Parser) ParseExpression() (IEvaluator, *Error) {
 
 	return exp, nil
 }
+
+func (p *Parser) parseCoalesceExpression() (IEvaluator, *Error) {
+	expr1, err := p.parseLogicalExpression()
+	if err != nil {
+		return nil, err
+	}
+
+	t := p.Match(TokenSymbol, "??")
+	if t == nil {
+		return expr1, nil
+	}
+
+	expr2, err := p.parseCoalesceExpression()
+	if err != nil {
+		return nil, err
+	}
+
+	return &coalesceExpression{
+		expr1:   expr1,
+		expr2:   expr2,
+		opToken: t,
+	}, nil
+}
+
+// ParseExpression parses an expression including the (right-associative)
+// ternary operator, which has the lowest precedence, followed by the
+// null-coalescing operator ??:
+//
+//	{{ a > b ? "greater" : "not greater" }}
+//	{{ user.nickname ?? user.name ?? "anonymous" }}
+//
+// Note that a ':' directly following a filter name starts the filter's
+// argument, so filtered branches without an argument must be wrapped
+// in parentheses: {{ active ? (name|upper) : "-" }}
+func (p *Parser) ParseExpression() (IEvaluator, *Error) {
+	cond, err := p.parseCoalesceExpression()
+	if err != nil {
+		return nil, err
+	}
+
+	t := p.Match(TokenSymbol, "?")
+	if t == nil {
+		return cond, nil
+	}
+
+	then, err := p.ParseExpression()
+	if err != nil {
+		return nil, err
+	}
+	if p.Match(TokenSymbol, ":") == nil {
+		return nil, p.Error("Expected ':' in conditional expression.", nil)
+	}
+	otherwise, err := p.ParseExpression()
+	if err != nil {
+		return nil, err
+	}
+
+	return &conditionalExpression{
+		condition: cond,
+		then:      then,
+		otherwise: otherwise,
+		opToken:   t,
+	}, nil
+}
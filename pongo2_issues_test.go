@@ -29,7 +29,7 @@ func TestIssue151(t *testing.T) {
 }
 
 func TestIssue297(t *testing.T) {
-	tpl, err := pongo2.FromString("Testing: {{ input|wordwrap:4 }}!")
+	tpl, err := pongo2.FromString("Testing: {{ input|wordwrap:13 }}!")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -39,7 +39,7 @@ func TestIssue297(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	if str != "Testing: one two three four\nfive six!" {
-		t.Fatalf("Expected `Testing: one two three four\nfive six!`, but got `%v`.", str)
+	if str != "Testing: one two three\nfour five six!" {
+		t.Fatalf("Expected `Testing: one two three\nfour five six!`, but got `%v`.", str)
 	}
 }
@@ -85,7 +85,7 @@ func tagSandboxDemoTagParser(doc *pongo2.Parser, start *pongo2.Token, arguments
 	return &tagSandboxDemoTag{}, nil
 }
 
-func BannedFilterFn(in *pongo2.Value, params *pongo2.Value) (*pongo2.Value, *pongo2.Error) {
+func BannedFilterFn(in *pongo2.Value, params *pongo2.Value, bind map[string]any) (*pongo2.Value, *pongo2.Error) {
 	return in, nil
 }
 
@@ -127,6 +127,7 @@ var tplContext = pongo2.Context{
 		"uint":                     uint(8),
 		"float":                    float64(3.1415),
 		"str":                      "string",
+		"bytes":                    []byte("pongo2 <3"),
 		"chinese_hello_world":      "你好世界",
 		"bool_true":                true,
 		"bool_false":               false,
@@ -148,6 +149,9 @@ Yep!`,
 		"xss":                "<script>alert(\"uh oh\");</script>",
 		"time1":              time1,
 		"time2":              time2,
+		"duration":           90 * time.Minute,
+		"negative_duration":  -(26*time.Hour + time.Minute + 1500*time.Millisecond),
+		"zero_duration":      time.Duration(0),
 		"stringer":           strPtr,
 		"stringerPtr":        &strPtr,
 		"intmap": map[int]string{
@@ -163,6 +167,7 @@ Yep!`,
 			"ukq": "qqa",
 			"aab": "aba",
 		},
+		"emptymap": map[string]string{},
 		"func_add": func(a, b int) int {
 			return a + b
 		},
@@ -413,6 +418,48 @@ func TestTemplates(t *testing.T) {
 	}
 }
 
+func TestTemplatesTokensRoundTrip(t *testing.T) {
+	pongo2.Globals["this_is_a_global_variable"] = "this is a global text"
+
+	matches, err := filepath.Glob("./template_tests/*.tpl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for idx, match := range matches {
+		t.Run(fmt.Sprintf("%03d-%s", idx+1, match), func(t *testing.T) {
+			tpl, err := pongo2.FromFile(match)
+			if err != nil {
+				t.Fatalf("Error on FromFile('%s'): %s", match, err.Error())
+			}
+			data, err := tpl.MarshalTokens()
+			if err != nil {
+				t.Fatalf("Error on MarshalTokens('%s'): %s", match, err.Error())
+			}
+			tpl, err = pongo2.DefaultSet.FromTokens(data)
+			if err != nil {
+				t.Fatalf("Error on FromTokens('%s'): %s", match, err.Error())
+			}
+
+			optsStr, _ := os.ReadFile(fmt.Sprintf("%s.options", match))
+			tpl.Options.TrimBlocks = strings.Contains(string(optsStr), "TrimBlocks=true")
+			tpl.Options.LStripBlocks = strings.Contains(string(optsStr), "LStripBlocks=true")
+
+			testOut, rerr := os.ReadFile(fmt.Sprintf("%s.out", match))
+			if rerr != nil {
+				t.Fatal(rerr)
+			}
+			tplOut, err := tpl.ExecuteBytes(tplContext)
+			if err != nil {
+				t.Fatalf("Error on Execute('%s'): %s", match, err.Error())
+			}
+			tplOut = testTemplateFixes.fixIfNeeded(match, tplOut)
+			if !bytes.Equal(testOut, tplOut) {
+				t.Errorf("Failed: test_out != tpl_out for %s after a tokens round-trip", match)
+			}
+		})
+	}
+}
+
 func TestBlockTemplates(t *testing.T) {
 	// debug = true
 
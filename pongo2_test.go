@@ -1,13 +1,24 @@
 package pongo2_test
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"math"
+	"math/big"
+	"math/rand"
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
+	"strings"
+	"sync"
 	"testing"
+	"testing/fstest"
+	"time"
 
 	"github.com/flosch/pongo2/v6"
 )
@@ -71,19 +82,1580 @@ func TestMisc(t *testing.T) {
 	mustEqual(t, pongo2.RegisterTag("for", nil).Error(), ".*is already registered")
 
 	// ApplyFilter
-	v, err := pongo2.ApplyFilter("title", pongo2.AsValue("this is a title"), nil)
+	v, err := pongo2.ApplyFilter("title", pongo2.AsValue("this is a title"), nil, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
 	mustEqual(t, v.String(), "This Is A Title")
 	mustPanicMatch(t, func() {
-		_, err := pongo2.ApplyFilter("doesnotexist", nil, nil)
+		_, err := pongo2.ApplyFilter("doesnotexist", nil, nil, nil)
 		if err != nil {
 			panic(err)
 		}
 	}, `\[Error \(where: applyfilter\)\] filter with name 'doesnotexist' not found`)
 }
 
+func TestForEmpty(t *testing.T) {
+	closedChan := func(items ...int) chan int {
+		c := make(chan int, len(items))
+		for _, i := range items {
+			c <- i
+		}
+		close(c)
+		return c
+	}
+
+	var sendOnly chan<- int = make(chan int)
+
+	tests := []struct {
+		name string
+		obj  any
+		want string
+	}{
+		{"empty slice", []string{}, "empty"},
+		{"nil", nil, "empty"},
+		{"populated slice", []string{"a", "b"}, "a1 b2 "},
+		{"empty map", map[string]int{}, "empty"},
+		{"populated map", map[string]int{"a": 1}, "a1 "},
+		{"empty channel", closedChan(), "empty"},
+		{"populated channel", closedChan(3, 4), "31 42 "},
+		{"nil channel", (chan int)(nil), "empty"},
+		{"send-only channel", sendOnly, "error"},
+	}
+
+	tpl, err := pongo2.FromString("{% for item in obj %}{{ item }}{{ forloop.Counter }} {% empty %}empty{% endfor %}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range tests {
+		out, err := tpl.Execute(pongo2.Context{"obj": test.obj})
+		if test.want == "error" {
+			if err == nil {
+				t.Fatalf("%s: expected an error", test.name)
+			}
+			mustEqual(t, err.Error(), ".*cannot iterate over a send-only channel.*")
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if out != test.want {
+			t.Errorf("%s: got %q, want %q", test.name, out, test.want)
+		}
+	}
+}
+
+func TestForUnclosedChannelCancellation(t *testing.T) {
+	tpl, err := pongo2.FromString("{% for item in obj %}{{ item }}{% endfor %}")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	goctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = tpl.ExecuteCtx(goctx, pongo2.Context{"obj": make(chan int)})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got %v, want an error wrapping %v", err, context.DeadlineExceeded)
+	}
+}
+
+type mockCacheBackend struct {
+	fragments map[string]string
+	ttls      map[string]time.Duration
+}
+
+func (m *mockCacheBackend) Get(key string) (string, bool) {
+	val, has := m.fragments[key]
+	return val, has
+}
+
+func (m *mockCacheBackend) Set(key string, val string, ttl time.Duration) {
+	m.fragments[key] = val
+	m.ttls[key] = ttl
+}
+
+func TestCacheTag(t *testing.T) {
+	backend := &mockCacheBackend{
+		fragments: make(map[string]string),
+		ttls:      make(map[string]time.Duration),
+	}
+	set := pongo2.NewSet("cache-test", pongo2.MustNewLocalFileSystemLoader(""))
+	set.CacheBackend = backend
+
+	tpl, err := set.FromString("{% cache 60 sidebar user %}{{ render() }}{% endcache %}")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	calls := 0
+	render := func() string {
+		calls++
+		return fmt.Sprintf("call %d", calls)
+	}
+
+	for i := 0; i < 2; i++ {
+		out, err := tpl.Execute(pongo2.Context{"render": render, "user": "flosch"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out != "call 1" {
+			t.Errorf("render %d: got %q, want %q", i+1, out, "call 1")
+		}
+	}
+	if calls != 1 {
+		t.Errorf("body evaluated %d times, want 1", calls)
+	}
+	if ttl := backend.ttls["pongo2.cache.sidebar:flosch"]; ttl != 60*time.Second {
+		t.Errorf("got ttl %v, want %v", ttl, 60*time.Second)
+	}
+
+	// Another vary-on value is another fragment
+	out, err := tpl.Execute(pongo2.Context{"render": render, "user": "other"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "call 2" {
+		t.Errorf("got %q, want %q", out, "call 2")
+	}
+}
+
+func TestCacheTagLoopControl(t *testing.T) {
+	tests := []struct {
+		tpl      string
+		want     string
+		uncached string
+	}{
+		{"{% for i in items %}{% cache 60 row i %}<{{ i }}>{% if i == 2 %}{% break %}{% endif %}{% endcache %}{% endfor %}", "<1><2>", "pongo2.cache.row:2"},
+		{"{% for i in items %}{% cache 60 row i %}<{{ i }}>{% if i == 2 %}{% continue %}{% endif %}!{% endcache %}{% endfor %}", "<1>!<2><3>!", "pongo2.cache.row:2"},
+	}
+	for _, test := range tests {
+		backend := &mockCacheBackend{
+			fragments: make(map[string]string),
+			ttls:      make(map[string]time.Duration),
+		}
+		set := pongo2.NewSet("cache-loop-test", pongo2.MustNewLocalFileSystemLoader(""))
+		set.CacheBackend = backend
+
+		tpl, err := set.FromString(test.tpl)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out, err := tpl.Execute(pongo2.Context{"items": []int{1, 2, 3}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out != test.want {
+			t.Errorf("%s: got %q, want %q", test.tpl, out, test.want)
+		}
+		if _, has := backend.fragments[test.uncached]; has {
+			t.Errorf("%s: the fragment interrupted by a loop control has been cached", test.tpl)
+		}
+		if val := backend.fragments["pongo2.cache.row:1"]; val == "" {
+			t.Errorf("%s: the complete fragment hasn't been cached", test.tpl)
+		}
+	}
+}
+
+func TestMemoryCache(t *testing.T) {
+	cache := pongo2.NewMemoryCache(2)
+
+	cache.Set("a", "1", time.Minute)
+	cache.Set("b", "2", time.Minute)
+	if _, has := cache.Get("a"); !has { // a is now the most recently used
+		t.Fatal("expected a to be cached")
+	}
+	cache.Set("c", "3", time.Minute)
+	if _, has := cache.Get("b"); has {
+		t.Error("expected b to be evicted")
+	}
+	if val, has := cache.Get("a"); !has || val != "1" {
+		t.Errorf("got %q (%v), want \"1\"", val, has)
+	}
+
+	cache.Set("expired", "x", -time.Second)
+	if _, has := cache.Get("expired"); has {
+		t.Error("expected an expired fragment to be missing")
+	}
+}
+
+func TestURLTag(t *testing.T) {
+	set := pongo2.NewSet("url-test", pongo2.MustNewLocalFileSystemLoader(""))
+	set.URLResolver = func(name string, args []*pongo2.Value, kwargs map[string]*pongo2.Value) (string, error) {
+		switch name {
+		case "article":
+			if len(args) != 2 {
+				return "", fmt.Errorf("expected 2 arguments, got %d", len(args))
+			}
+			return fmt.Sprintf("/articles/%s/%s/", args[0].String(), args[1].String()), nil
+		case "user":
+			id, has := kwargs["id"]
+			if !has {
+				return "", errors.New("missing id")
+			}
+			return fmt.Sprintf("/users/%d/?tab=%s&x=1", id.Integer(), kwargs["tab"].String()), nil
+		}
+		return "", fmt.Errorf("no route named '%s'", name)
+	}
+
+	tests := []struct {
+		name string
+		tpl  string
+		want string
+	}{
+		{"positional", `{% url "article" 2014 slug %}`, "/articles/2014/hello-world/"},
+		{"keyword", `{% url "user" id=user.ID tab="posts" %}`, "/users/42/?tab=posts&amp;x=1"},
+		{"as", `{% url "user" tab="info" id=user.ID as link %}<a href="{{ link }}">{{ link|length }}</a>`, `<a href="/users/42/?tab=info&amp;x=1">23</a>`},
+	}
+
+	ctx := pongo2.Context{
+		"slug": "hello-world",
+		"user": struct{ ID int }{42},
+	}
+	for _, test := range tests {
+		tpl, err := set.FromString(test.tpl)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		out, err := tpl.Execute(ctx)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if out != test.want {
+			t.Errorf("%s: got %q, want %q", test.name, out, test.want)
+		}
+	}
+
+	tpl, err := set.FromString(`foo {% url "missing" %}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = tpl.Execute(ctx)
+	mustEqual(t, fmt.Sprintf("%v", err), `\[Error \(where: execution\) in <string> \| Line 1 Col 8 near 'url'\] resolving URL 'missing' failed: no route named 'missing'`)
+
+	// Malformed tags
+	for _, tpl := range []string{
+		`{% url %}`,
+		`{% url "user" id=1 2 %}`,
+		`{% url "user" id=1 id=2 %}`,
+		`{% url "user" as %}`,
+		`{% url "user" as link foo %}`,
+	} {
+		if _, err := set.FromString(tpl); err == nil {
+			t.Errorf("%s: expected a compilation error", tpl)
+		}
+	}
+}
+
+type mapTranslator map[string]string
+
+func (m mapTranslator) Translate(ctx *pongo2.ExecutionContext, msgid string, vars map[string]any) string {
+	if translated, has := m[msgid]; has {
+		return translated
+	}
+	return msgid
+}
+
+func TestTransTags(t *testing.T) {
+	tests := []struct {
+		name string
+		tpl  string
+		want string
+	}{
+		{"trans", `{% trans "Hello" %}`, "Hallo"},
+		{"trans untranslated", `{% trans "Tom's" %}`, "Tom's"},
+		{"trans variable", `{% trans greeting %}`, "Hallo"},
+		{"trans escaped variable", `{% trans html %}`, "&lt;b&gt;"},
+		{"trans as", `{% trans "Hello" as hello %}[{{ hello }}]`, "[Hallo]"},
+		{"blocktrans", `{% blocktrans %}Hello {{ name }}!{% endblocktrans %}`, "Hallo Florian!"},
+		{"blocktrans escaped", `{% blocktrans %}Hello {{ html }}!{% endblocktrans %}`, "Hallo &lt;b&gt;!"},
+		{"blocktrans with", `{% blocktrans with name=user|upper %}Hello {{ name }}!{% endblocktrans %}`, "Hallo FLOSCH!"},
+		{"blocktrans singular", `{% blocktrans count counter=one|length %}{{ counter }} item{% plural %}{{ counter }} items{% endblocktrans %}`, "1 Eintrag"},
+		{"blocktrans plural", `{% blocktrans count counter=three|length %}{{ counter }} item{% plural %}{{ counter }} items{% endblocktrans %}`, "3 Einträge"},
+		{"blocktrans plural with", `{% blocktrans with name=user count n=three|length %}{{ name }} has one item{% plural %}{{ name }} has {{ n }} items{% endblocktrans %}`, "flosch hat 3 Einträge"},
+	}
+
+	ctx := pongo2.Context{
+		"greeting": "Hello",
+		"html":     "<b>",
+		"name":     "Florian",
+		"user":     "flosch",
+		"one":      []int{1},
+		"three":    []int{1, 2, 3},
+	}
+
+	translated := pongo2.NewSet("trans-test", pongo2.MustNewLocalFileSystemLoader(""))
+	translated.Translator = mapTranslator{
+		"Hello":                        "Hallo",
+		"<b>":                          "<b>",
+		"Hello {{ name }}!":            "Hallo {{ name }}!",
+		"Hello {{ html }}!":            "Hallo {{ html }}!",
+		"{{ counter }} item":           "{{ counter }} Eintrag",
+		"{{ counter }} items":          "{{ counter }} Einträge",
+		"{{ name }} has {{ n }} items": "{{ name }} hat {{ n }} Einträge",
+		"{{ name }} has one item":      "{{ name }} hat einen Eintrag",
+	}
+	for _, test := range tests {
+		tpl, err := translated.FromString(test.tpl)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		out, err := tpl.Execute(ctx)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if out != test.want {
+			t.Errorf("%s: got %q, want %q", test.name, out, test.want)
+		}
+	}
+
+	// Without a translator the source messages are output
+	untranslated := pongo2.NewSet("trans-test-untranslated", pongo2.MustNewLocalFileSystemLoader(""))
+	out, err := untranslated.RenderTemplateString(`{% trans "Hello" %} {% blocktrans count counter=three|length %}{{ counter }} item{% plural %}{{ counter }} items{% endblocktrans %}`, ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "Hello 3 items" {
+		t.Errorf("got %q, want %q", out, "Hello 3 items")
+	}
+
+	// Malformed tags
+	for _, tpl := range []string{
+		`{% trans %}`,
+		`{% trans "a" "b" %}`,
+		`{% blocktrans %}{{ user.name }}{% endblocktrans %}`,
+		`{% blocktrans %}{{ name|upper }}{% endblocktrans %}`,
+		`{% blocktrans %}{% if true %}{% endif %}{% endblocktrans %}`,
+		`{% blocktrans %}a{% plural %}b{% endblocktrans %}`,
+		`{% blocktrans count n=1 %}a{% endblocktrans %}`,
+		`{% blocktrans with %}a{% endblocktrans %}`,
+		`{% blocktrans %}a`,
+	} {
+		if _, err := untranslated.FromString(tpl); err == nil {
+			t.Errorf("%s: expected a compilation error", tpl)
+		}
+	}
+}
+
+func TestDebugTag(t *testing.T) {
+	type profile struct {
+		Name   string
+		Tags   []string
+		hidden int
+	}
+	ctx := pongo2.Context{
+		"profile": &profile{Name: "flosch", Tags: []string{"a"}, hidden: 1},
+		"scores":  map[string]int{"b": 2, "a": 1},
+		"html":    "<b>",
+	}
+
+	set := pongo2.NewSet("debug-test", pongo2.MustNewLocalFileSystemLoader(""))
+	tpl, err := set.FromString("{% debug profile %}|{% debug scores %}|{% debug html %}|{% debug missing %}")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := tpl.Execute(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "|||" {
+		t.Errorf("got %q in non-debug mode, want %q", out, "|||")
+	}
+
+	set.Debug = true
+	out, err = tpl.Execute(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `&amp;pongo2_test.profile{
+    Name: &quot;flosch&quot;,
+    Tags: []string{
+        &quot;a&quot;,
+    },
+}|map[string]int{
+    &quot;a&quot;: int(1),
+    &quot;b&quot;: int(2),
+}|&quot;&lt;b&gt;&quot;|nil`
+	if out != want {
+		t.Errorf("got %q, want %q", out, want)
+	}
+}
+
+func TestNowTag(t *testing.T) {
+	fixed := time.Date(2022, time.March, 4, 23, 30, 0, 0, time.UTC)
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Skipf("timezone database not available: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		tpl  string
+		ctx  pongo2.Context
+		want string
+	}{
+		{"utc", `{% now "2006-01-02 15:04" "UTC" %}`, pongo2.Context{"__now__": fixed}, "2022-03-04 23:30"},
+		{"tokyo", `{% now "2006-01-02 15:04 MST" "Asia/Tokyo" %}`, pongo2.Context{"__now__": fixed}, "2022-03-05 08:30 JST"},
+		{"location", `{% now "2006-01-02 15:04" tz %}`, pongo2.Context{"__now__": fixed, "tz": tokyo}, "2022-03-05 08:30"},
+		{"clock func", `{% now "15:04" "UTC" %}`, pongo2.Context{"__now__": func() time.Time { return fixed }}, "23:30"},
+		{"django format", `{% now "django:Y-m-d H:i" "America/New_York" %}`, pongo2.Context{"__now__": fixed}, "2022-03-04 18:30"},
+		{"as", `{% now "2006" "UTC" as year %}[{{ year }}]`, pongo2.Context{"__now__": fixed}, "[2022]"},
+		{"fake as", `{% now "2006-01-02" fake as day %}{{ day }}`, nil, "2014-02-05"},
+	}
+	for _, test := range tests {
+		out, err := pongo2.RenderTemplateString(test.tpl, test.ctx)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if out != test.want {
+			t.Errorf("%s: got %q, want %q", test.name, out, test.want)
+		}
+	}
+
+	if _, err := pongo2.RenderTemplateString(`{% now "2006" "Nowhere/Unknown" %}`, nil); err == nil {
+		t.Error("expected an error for an unknown timezone")
+	}
+	if _, err := pongo2.FromString(`{% now "2006" as %}`); err == nil {
+		t.Error("expected a compilation error for a missing name after 'as'")
+	}
+}
+
+var errWriterLimit = errors.New("writer limit reached")
+
+// limitedWriter fails as soon as more than limit bytes would be written
+type limitedWriter struct {
+	limit   int
+	written []byte
+}
+
+func (w *limitedWriter) Write(p []byte) (int, error) {
+	if len(w.written)+len(p) > w.limit {
+		return 0, errWriterLimit
+	}
+	w.written = append(w.written, p...)
+	return len(p), nil
+}
+
+func TestExecuteWriterUnbufferedWriteError(t *testing.T) {
+	tpl, err := pongo2.FromString("{% for i in items %}{{ i }},{% endfor %}{{ finish() }}")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	finished := false
+	w := &limitedWriter{limit: 10}
+	err = tpl.ExecuteWriterUnbuffered(pongo2.Context{
+		"items":  []int{1, 2, 3, 4, 5, 6, 7, 8, 9},
+		"finish": func() string { finished = true; return "" },
+	}, w)
+	if !errors.Is(err, errWriterLimit) {
+		t.Fatalf("got error %v, want %v", err, errWriterLimit)
+	}
+	if string(w.written) != "1,2,3,4,5," {
+		t.Errorf("got partial output %q, want %q", string(w.written), "1,2,3,4,5,")
+	}
+	if finished {
+		t.Error("execution continued after the write error")
+	}
+}
+
+func TestExecuteWriterUnbufferedWriteErrorInInclude(t *testing.T) {
+	set := pongo2.NewSet("unbuffered include", pongo2.NewFSLoader(fstest.MapFS{
+		"part.html": {Data: []byte(`{% for i in items %}{{ i }},{% endfor %}`)},
+	}))
+
+	for _, src := range []string{
+		`{% include "part.html" %}{{ finish() }}`,
+		`{% include name %}{{ finish() }}`,
+		`{% ssi "part.html" parsed %}{{ finish() }}`,
+	} {
+		tpl, err := set.FromString(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		finished := false
+		w := &limitedWriter{limit: 10}
+		err = tpl.ExecuteWriterUnbuffered(pongo2.Context{
+			"name":   "part.html",
+			"items":  []int{1, 2, 3, 4, 5, 6, 7, 8, 9},
+			"finish": func() string { finished = true; return "" },
+		}, w)
+		if !errors.Is(err, errWriterLimit) {
+			t.Fatalf("%s: got error %v, want %v", src, err, errWriterLimit)
+		}
+		if finished {
+			t.Errorf("%s: execution continued after the write error", src)
+		}
+	}
+}
+
+func TestExecuteBlock(t *testing.T) {
+	tpl, err := pongo2.FromFile("template_tests/extends_super5.tpl")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		block string
+		want  string
+	}{
+		{"content", "[Default contentextends-level-1extends-level-2]extends-level-3"},
+		{"body", "This is base's body[Default contentextends-level-1extends-level-2]extends-level-3"},
+	}
+	for _, test := range tests {
+		out, err := tpl.ExecuteBlock(test.block, nil)
+		if err != nil {
+			t.Fatalf("%s: %v", test.block, err)
+		}
+		if out != test.want {
+			t.Errorf("%s: got %q, want %q", test.block, out, test.want)
+		}
+
+		var b bytes.Buffer
+		if err := tpl.ExecuteBlockWriter(test.block, nil, &b); err != nil {
+			t.Fatalf("%s: %v", test.block, err)
+		}
+		if b.String() != test.want {
+			t.Errorf("%s (writer): got %q, want %q", test.block, b.String(), test.want)
+		}
+	}
+
+	tpl, err = pongo2.FromString("{% block title %}{{ title }}{% endblock %}|{% block content %}{{ content }}{% endblock %}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := tpl.ExecuteBlock("content", pongo2.Context{"title": "Title", "content": "<p>"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "&lt;p&gt;" {
+		t.Errorf("got %q, want %q", out, "&lt;p&gt;")
+	}
+
+	_, err = tpl.ExecuteBlock("missing", nil)
+	mustEqual(t, fmt.Sprintf("%v", err), "block 'missing' not found")
+}
+
+func TestExecuteCtxCancellation(t *testing.T) {
+	tpl, err := pongo2.FromString("{% for i in items %}{{ tick(i) }}{% endfor %}")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	goctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	items := make([]int, 1000000)
+	ticks := 0
+	out, err := tpl.ExecuteCtx(goctx, pongo2.Context{
+		"items": items,
+		"tick": func(i int) string {
+			ticks++
+			if ticks == 1000 {
+				cancel()
+			}
+			return ""
+		},
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("got error %v, want %v", err, context.Canceled)
+	}
+	if out != "" {
+		t.Errorf("got output %q on error", out)
+	}
+	if ticks >= len(items) {
+		t.Errorf("execution wasn't aborted (%d iterations)", ticks)
+	}
+
+	// An already cancelled context doesn't execute anything
+	ticks = 0
+	_, err = tpl.ExecuteCtx(goctx, pongo2.Context{"items": items, "tick": func(i int) string { ticks++; return "" }})
+	if !errors.Is(err, context.Canceled) || ticks != 0 {
+		t.Errorf("got error %v after %d iterations, want %v immediately", err, ticks, context.Canceled)
+	}
+
+	// Without cancellation everything is rendered
+	out, err = tpl.ExecuteCtx(context.Background(), pongo2.Context{"items": []int{1, 2}, "tick": func(i int) int { return i }})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "12" {
+		t.Errorf("got %q, want %q", out, "12")
+	}
+}
+
+func TestSandboxedSet(t *testing.T) {
+	set := pongo2.NewSet("sandboxed", pongo2.MustNewLocalFileSystemLoader(""))
+	set.Sandboxed = true
+	if err := set.BanFilter("safe"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tpl := range []string{
+		`{% include "template_tests/with.helper" %}`,
+		`{% ssi "template_tests/ssi.helper" %}`,
+		`{% include_raw "template_tests/ssi.helper" %}`,
+		`{% import "template_tests/macro.helper" imported_macro %}`,
+		`{% extends "template_tests/inheritance/base.tpl" %}`,
+		`{{ "<b>"|safe }}`,
+		`{% filter safe %}<b>{% endfilter %}`,
+	} {
+		_, err := set.FromString(tpl)
+		mustEqual(t, fmt.Sprintf("%v", err), `Usage of (tag|filter) '(include|include_raw|ssi|import|extends|safe)' is not allowed \(sandbox restriction active\)\.`)
+	}
+
+	out, err := set.RenderTemplateString(`{% for i in items %}{{ i|upper }}{% endfor %}`, pongo2.Context{"items": []string{"a", "b"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "AB" {
+		t.Errorf("got %q, want %q", out, "AB")
+	}
+
+	// A filter applied by name is only known at render time
+	_, err = set.RenderTemplateString(`{{ "<b>"|apply:"safe" }}`, nil)
+	mustEqual(t, fmt.Sprintf("%v", err), `usage of filter 'safe' is not allowed \(sandbox restriction active\)`)
+}
+
+func TestBanAllTagsExcept(t *testing.T) {
+	set := pongo2.NewSet("whitelist", pongo2.MustNewLocalFileSystemLoader(""))
+	if err := set.BanAllTagsExcept("if", "for"); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := set.RenderTemplateString(`{% for i in items %}{% if i != "b" %}{{ i }}{% endif %}{% endfor %}`, pongo2.Context{"items": []string{"a", "b", "c"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "ac" {
+		t.Errorf("got %q, want %q", out, "ac")
+	}
+
+	for _, tpl := range []string{
+		`{% set x = 1 %}`,
+		`{% with x=1 %}{% endwith %}`,
+		`{% banned_tag %}`,
+	} {
+		_, err := set.FromString(tpl)
+		mustEqual(t, fmt.Sprintf("%v", err), `Usage of tag '(set|with|banned_tag)' is not allowed \(sandbox restriction active\)\.`)
+	}
+
+	if err := set.BanAllTagsExcept("if"); err == nil {
+		t.Error("expected an error banning tags after the first template was created")
+	}
+	if err := pongo2.NewSet("whitelist 2", pongo2.MustNewLocalFileSystemLoader("")).BanAllTagsExcept("no_such_tag"); err == nil {
+		t.Error("expected an error allowing an unknown tag")
+	}
+}
+
+func TestCustomDelimiters(t *testing.T) {
+	ctx := pongo2.Context{"name": "flosch", "items": []int{1, 2}}
+	want := "Hello FLOSCH!12 {{ vue }} {% raw %}"
+
+	defaultSet := pongo2.NewSet("default delimiters", pongo2.MustNewLocalFileSystemLoader(""))
+	out, err := defaultSet.RenderTemplateString(
+		`Hello {{ name|upper }}!{# comment #}{% for i in items %}{{ i }}{% endfor %}{% verbatim %} {{ vue }} {% raw %}{% endverbatim %}`, ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != want {
+		t.Errorf("default delimiters: got %q, want %q", out, want)
+	}
+
+	customSet := pongo2.NewSet("custom delimiters", pongo2.MustNewLocalFileSystemLoader(""))
+	if err := customSet.SetDelimiters("[[", "]]", "[%", "%]"); err != nil {
+		t.Fatal(err)
+	}
+	if err := customSet.SetCommentDelimiters("<#", "#>"); err != nil {
+		t.Fatal(err)
+	}
+	out, err = customSet.RenderTemplateString(
+		`Hello [[ name|upper ]]!<# comment #>[% for i in items %][[ i ]][% endfor %][% verbatim %] {{ vue }} {% raw %}[% endverbatim %]`, ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != want {
+		t.Errorf("custom delimiters: got %q, want %q", out, want)
+	}
+
+	// Whitespace control and string literals containing delimiters
+	out, err = customSet.RenderTemplateString("[% if true -%]\n  [[- \"]]\" ]]\n[%- endif %]", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "]]" {
+		t.Errorf("got %q, want %q", out, "]]")
+	}
+
+	// templatetag outputs the configured delimiters
+	out, err = customSet.RenderTemplateString(
+		"[% templatetag openvariable %] [% templatetag closevariable %] [% templatetag openblock %] [% templatetag closeblock %] "+
+			"[% templatetag opencomment %] [% templatetag closecomment %] [% templatetag openbrace %][% templatetag closebrace %]", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "[[ ]] [% %] <# #> {}"; out != want {
+		t.Errorf("templatetag: got %q, want %q", out, want)
+	}
+
+	for _, delims := range [][]string{
+		{"", "]]", "[%", "%]"},
+		{"[[", "]]", "[[", "%]"},
+		{"[ [", "]]", "[%", "%]"},
+		{"[", "]]", "[%", "%]"},
+	} {
+		if err := customSet.SetDelimiters(delims[0], delims[1], delims[2], delims[3]); err == nil {
+			t.Errorf("%q: expected an error", delims)
+		}
+	}
+}
+
+func TestSetGlobals(t *testing.T) {
+	set := pongo2.NewSet("globals", pongo2.MustNewLocalFileSystemLoader(""))
+	set.Globals["site_name"] = "pongo2"
+	set.Globals["csrf_token"] = "abc"
+	set.Globals["upper"] = strings.ToUpper
+
+	tpl, err := set.FromString(`{{ site_name }}|{{ csrf_token }}|{{ upper(site_name) }}|{{ site_name|length }}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := tpl.Execute(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mustEqual(t, out, "pongo2\\|abc\\|PONGO2\\|6")
+
+	// Per-render values take precedence
+	out, err = tpl.Execute(pongo2.Context{"site_name": "other"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mustEqual(t, out, "other\\|abc\\|OTHER\\|5")
+
+	// The globals aren't modified by an execution
+	mustEqual(t, set.Globals["site_name"].(string), "pongo2")
+}
+
+func TestContextMerge(t *testing.T) {
+	base := pongo2.Context{"a": 1, "b": 2}
+	other := pongo2.Context{"b": 3, "c": 4}
+
+	merged := base.Merge(other)
+	mustEqual(t, fmt.Sprint(merged), "map\\[a:1 b:3 c:4\\]")
+	mustEqual(t, fmt.Sprint(base), "map\\[a:1 b:2\\]")
+	mustEqual(t, fmt.Sprint(other), "map\\[b:3 c:4\\]")
+
+	base.Update(other)
+	mustEqual(t, fmt.Sprint(base), "map\\[a:1 b:3 c:4\\]")
+}
+
+func TestContextValidate(t *testing.T) {
+	if err := (pongo2.Context{"name": 1, "_private": 2, "item2": 3}).Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]string{
+		"user name": "context-key 'user name' is not a valid variable name",
+		"2fa":       "context-key '2fa' is not a valid variable name",
+		"user-id":   "context-key 'user-id' is not a valid variable name",
+		"":          "context-key '' is not a valid variable name",
+		"in":        "context-key 'in' is a keyword and can't be used as a variable name",
+	}
+	for key, want := range tests {
+		err := pongo2.Context{"valid": 1, key: 2}.Validate()
+		if err == nil {
+			t.Errorf("expected an error for key %q", key)
+			continue
+		}
+		if err.Error() != want {
+			t.Errorf("got %q, want %q", err.Error(), want)
+		}
+	}
+}
+
+func TestConcurrentExecute(t *testing.T) {
+	tpl, err := pongo2.FromString(`{% set name = "set-"|add:id %}{% set base = base|add:"!" %}{{ name }}:{{ items|length }}:{{ base }}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Shared by all executions
+	ctx := pongo2.Context{"base": "shared", "items": []int{1, 2, 3}}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 100)
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			out, err := tpl.Execute(ctx.Merge(pongo2.Context{"id": id}))
+			if err != nil {
+				errs <- err
+				return
+			}
+			if want := fmt.Sprintf("set-%d:3:shared!", id); out != want {
+				errs <- fmt.Errorf("got %q, want %q", out, want)
+			}
+			if out, err := tpl.Execute(ctx); err != nil || out != "set-:3:shared!" {
+				errs <- fmt.Errorf("got %q (%v), want %q", out, err, "set-:3:shared!")
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	mustEqual(t, fmt.Sprint(len(ctx)), "2")
+	mustEqual(t, ctx["base"].(string), "shared")
+}
+
+func TestBigNumbers(t *testing.T) {
+	huge, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+	price, _ := new(big.Float).SetPrec(200).SetString("10000000000000000000.25")
+	ctx := pongo2.Context{"huge": huge, "price": price, "maxint": int64(math.MaxInt64)}
+
+	tests := []struct {
+		tpl  string
+		want string
+	}{
+		{"{{ huge }}", "123456789012345678901234567890"},
+		{"{{ huge + 10 }}", "123456789012345678901234567900"},
+		{"{{ 10 + huge }}", "123456789012345678901234567900"},
+		{"{{ huge - huge - 1 }}", "-1"},
+		{"{{ -huge }}", "-123456789012345678901234567890"},
+		{"{{ huge * 2 }}", "246913578024691357802469135780"},
+		{"{{ huge / 10 }}", "12345678901234567890123456789"},
+		{"{{ huge % 11 }}", "7"},
+		{"{{ huge|add:maxint }}", "123456789021569050938089343697"},
+		{"{{ huge|divisibleby:10 }}", "True"},
+		{"{{ huge|divisibleby:11 }}", "False"},
+		{"{{ huge > maxint }}", "True"},
+		{"{{ huge < maxint }}", "False"},
+		{"{{ huge == huge + 0 }}", "True"},
+		{"{{ huge != huge + 1 }}", "True"},
+		{"{% if huge %}yes{% endif %}", "yes"},
+		{"{{ price }}", "10000000000000000000.25"},
+		{"{{ price + 1 }}", "10000000000000000001.25"},
+		{"{{ price * 2 }}", "20000000000000000000.5"},
+		{"{{ price > huge }}", "False"},
+	}
+	for _, test := range tests {
+		tpl, err := pongo2.FromString(test.tpl)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out, err := tpl.Execute(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out != test.want {
+			t.Errorf("%s: got %q, want %q", test.tpl, out, test.want)
+		}
+	}
+
+	tpl, err := pongo2.FromString("{{ huge / 0 }}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = tpl.Execute(ctx)
+	mustEqual(t, fmt.Sprintf("%v", err), ".*integer divide by zero")
+}
+
+func TestNumberChecksDontAllocate(t *testing.T) {
+	// Values resolved from slice items (like within a for-loop) are
+	// addressable, turning them into an interface{} would allocate
+	check := func(v *pongo2.Value) int {
+		return int(testing.AllocsPerRun(100, func() {
+			_ = v.IsNumber()
+			_ = v.IsBigNumber()
+		}))
+	}
+	out, err := pongo2.RenderTemplateString(`{{ check(ints.0) }} {{ check(floats.0) }} {{ check(strs.0) }}`, pongo2.Context{
+		"check":  check,
+		"ints":   []int{100000},
+		"floats": []float64{3.14},
+		"strs":   []string{"x"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "0 0 0" {
+		t.Errorf("number checks allocated: got %q, want %q", out, "0 0 0")
+	}
+}
+
+type nilSafeLevel3 struct {
+	Name string
+}
+
+func (l *nilSafeLevel3) Title() string {
+	return strings.ToUpper(l.Name)
+}
+
+type nilSafeLevel2 struct {
+	Level3 *nilSafeLevel3
+	Tags   []string
+}
+
+type nilSafeLevel1 struct {
+	Level2 *nilSafeLevel2
+}
+
+func TestNilSafeAttributes(t *testing.T) {
+	ctx := pongo2.Context{
+		"set":   nilSafeLevel1{Level2: &nilSafeLevel2{Level3: &nilSafeLevel3{Name: "pongo"}}},
+		"unset": nilSafeLevel1{Level2: &nilSafeLevel2{}},
+		"nil2":  nilSafeLevel1{},
+	}
+
+	render := func(set *pongo2.TemplateSet, src string) (string, error) {
+		tpl, err := set.FromString(src)
+		if err != nil {
+			return "", err
+		}
+		return tpl.Execute(ctx)
+	}
+
+	defaultSet := pongo2.NewSet("nil-safe default", pongo2.MustNewLocalFileSystemLoader(""))
+	nilSafeSet := pongo2.NewSet("nil-safe", pongo2.MustNewLocalFileSystemLoader(""))
+	nilSafeSet.NilSafeAttributes = true
+
+	for _, set := range []*pongo2.TemplateSet{defaultSet, nilSafeSet} {
+		for src, want := range map[string]string{
+			"{{ set.Level2.Level3.Name }}":    "pongo",
+			"{{ set.Level2.Level3.Title }}":   "PONGO",
+			"[{{ nil2.Level2.Level3.Name }}]": "[]",
+			"[{{ unset.Level2.Level3 }}]":     "[]",
+			"[{{ unset.Level2.Tags.0 }}]":     "[]",
+		} {
+			out, err := render(set, src)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if out != want {
+				t.Errorf("%s: got %q, want %q", src, out, want)
+			}
+		}
+	}
+
+	// Default mode: a nil slice has no fields
+	_, err := render(defaultSet, "{{ unset.Level2.Tags.Name }}")
+	mustEqual(t, fmt.Sprintf("%v", err), ".*can't access a field by name on type slice.*")
+
+	// Nil-safe mode: nil intermediates (including method receivers) resolve to nil
+	for _, src := range []string{
+		"{{ unset.Level2.Tags.Name }}",
+		"{{ unset.Level2.Level3.Title }}",
+		"{{ nil2.Level2.Tags.Name }}",
+		"{{ nil2.Level2.Level3.Title }}",
+	} {
+		out, err := render(nilSafeSet, "["+src+"]")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out != "[]" {
+			t.Errorf("%s: got %q, want %q", src, out, "[]")
+		}
+	}
+}
+
+func TestTemplateTokens(t *testing.T) {
+	if err := pongo2.RegisterFilter("tokens_test_filter", func(in, param *pongo2.Value, bind map[string]any) (*pongo2.Value, *pongo2.Error) {
+		return pongo2.AsValue("[" + in.String() + "]"), nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	set := pongo2.NewSet("tokens", pongo2.MustNewLocalFileSystemLoader(""))
+	tpl, err := set.FromString(`{% macro greet(name) %}Hello {{ name|tokens_test_filter }}!{% endmacro %}{% for n in names %}{{ greet(n) }}{% endfor %}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := pongo2.Context{"names": []string{"a", "b"}}
+	want, err := tpl.Execute(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := tpl.MarshalTokens()
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := set.FromTokens(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := loaded.Execute(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mustEqual(t, got, regexp.QuoteMeta(want))
+	mustEqual(t, got, regexp.QuoteMeta("Hello [a]!Hello [b]!"))
+
+	// The tokens are parsed again when loading, so tags are checked
+	bannedSet := pongo2.NewSet("tokens banned", pongo2.MustNewLocalFileSystemLoader(""))
+	if err := bannedSet.BanTag("macro"); err != nil {
+		t.Fatal(err)
+	}
+	_, err = bannedSet.FromTokens(data)
+	mustEqual(t, fmt.Sprintf("%v", err), ".*Usage of tag 'macro' is not allowed.*")
+
+	// Filters as well
+	if err := pongo2.UnregisterFilter("tokens_test_filter"); err != nil {
+		t.Fatal(err)
+	}
+	_, err = set.FromTokens(data)
+	mustEqual(t, fmt.Sprintf("%v", err), ".*tokens_test_filter.*")
+
+	_, err = set.FromTokens([]byte("garbage"))
+	mustEqual(t, fmt.Sprintf("%v", err), ".*fromtokens.*")
+}
+
+func TestIncludeIgnoreMissing(t *testing.T) {
+	set := pongo2.NewSet("include-ignore-missing", pongo2.NewFSLoader(fstest.MapFS{
+		"partial.html": {Data: []byte("[partial]")},
+		"broken.html":  {Data: []byte("{% if %}broken{% endif %}")},
+	}))
+
+	render := func(src string, ctx pongo2.Context) (string, error) {
+		tpl, err := set.FromString(src)
+		if err != nil {
+			return "", err
+		}
+		return tpl.Execute(ctx)
+	}
+
+	for _, src := range []string{
+		`{% include "missing.html" ignore missing %}|{% include "partial.html" ignore missing %}`,
+		`{% include name ignore missing %}|{% include "partial.html" ignore missing %}`,
+	} {
+		out, err := render(src, pongo2.Context{"name": "missing.html"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		mustEqual(t, out, `^\|\[partial\]$`)
+	}
+
+	// Templates which exist but fail to parse still report their errors
+	for _, src := range []string{
+		`{% include "broken.html" ignore missing %}`,
+		`{% include name ignore missing %}`,
+	} {
+		_, err := render(src, pongo2.Context{"name": "broken.html"})
+		mustEqual(t, fmt.Sprintf("%v", err), regexp.QuoteMeta("[Error (where: parser) in broken.html | Line 1 Col 4 near 'if'] "))
+	}
+}
+
+func TestIncludeRaw(t *testing.T) {
+	set := pongo2.NewSet("include-raw", pongo2.NewFSLoader(fstest.MapFS{
+		"logo.svg": {Data: []byte(`<svg>{{ braces }}{% if %}</svg>`)},
+	}))
+
+	render := func(src string, ctx pongo2.Context) (string, error) {
+		tpl, err := set.FromString(src)
+		if err != nil {
+			return "", err
+		}
+		return tpl.Execute(ctx)
+	}
+
+	out, err := render(`{% include_raw "logo.svg" %}|{% include_raw name escaped %}`, pongo2.Context{"name": "logo.svg", "braces": "x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mustEqual(t, out, regexp.QuoteMeta(`<svg>{{ braces }}{% if %}</svg>|&lt;svg&gt;{{ braces }}{% if %}&lt;/svg&gt;`))
+
+	// Missing files are errors unless being ignored
+	_, err = render(`{% include_raw "missing.svg" %}`, nil)
+	mustEqual(t, fmt.Sprintf("%v", err), `\[Error \(where: tag:include_raw\) in missing.svg \| Line 1 Col 16 near 'missing.svg'\] unable to resolve template`)
+	_, err = render(`{% include_raw name %}`, pongo2.Context{"name": "missing.svg"})
+	mustEqual(t, fmt.Sprintf("%v", err), `\[Error \(where: tag:include_raw\) in missing.svg\] unable to resolve template`)
+	_, err = render(`{% include_raw "logo.svg" ignore %}`, nil)
+	mustEqual(t, fmt.Sprintf("%v", err), `Expected 'missing' after 'ignore'\.`)
+	_, err = render(`{% include_raw "logo.svg" parsed %}`, nil)
+	mustEqual(t, fmt.Sprintf("%v", err), `Malformed 'include_raw'-tag arguments\.`)
+}
+
+func TestLoremSeeded(t *testing.T) {
+	render := func(src string, seed int64) string {
+		out, err := pongo2.RenderTemplateString(src, pongo2.Context{"rand": rand.New(rand.NewSource(seed))})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return out
+	}
+
+	words := render("{% lorem 12 w random %}", 1)
+	if n := len(strings.Fields(words)); n != 12 {
+		t.Fatalf("expected 12 words, got %d: %q", n, words)
+	}
+	if again := render("{% lorem 12 w random %}", 1); again != words {
+		t.Errorf("same seed rendered different words: %q != %q", again, words)
+	}
+	mustEqual(t, render("{% lorem 5 w %}", 1), "^Lorem ipsum dolor sit amet,$")
+
+	paragraphs := render("{% lorem 3 p random %}", 7)
+	mustEqual(t, paragraphs, "^<p>[^<]+</p>\n<p>[^<]+</p>\n<p>[^<]+</p>$")
+	if again := render("{% lorem 3 p random %}", 7); again != paragraphs {
+		t.Errorf("same seed rendered different paragraphs: %q != %q", again, paragraphs)
+	}
+}
+
+func TestTemplateBlocks(t *testing.T) {
+	set := pongo2.NewSet("blocks", pongo2.NewFSLoader(fstest.MapFS{
+		"base.html": {Data: []byte("<html>{% block head %}<title>{% block title %}{% endblock %}</title>{% endblock %}\n" +
+			"<body>\n  {% block content %}\n    {% block inner %}{% endblock inner %}\n  {% endblock %}\n</body>")},
+		"child.html": {Data: []byte(`{% extends "base.html" %}{% block title %}Child{% endblock %}`)},
+	}))
+
+	tpl, err := set.FromFile("base.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mustEqual(t, fmt.Sprintf("%+v", tpl.Blocks()), regexp.QuoteMeta("["+
+		"{Name:head Line:1 Col:10 Parent: InChild:false} "+
+		"{Name:title Line:1 Col:33 Parent:head InChild:false} "+
+		"{Name:content Line:3 Col:6 Parent: InChild:false} "+
+		"{Name:inner Line:4 Col:8 Parent:content InChild:false}]"))
+
+	tpl, err = set.FromFile("child.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mustEqual(t, fmt.Sprintf("%+v", tpl.Blocks()), regexp.QuoteMeta("[{Name:title Line:1 Col:29 Parent: InChild:true}]"))
+
+	tpl, err = set.FromString("no blocks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mustEqual(t, fmt.Sprint(len(tpl.Blocks())), "0")
+}
+
+func TestCollectParseErrors(t *testing.T) {
+	src := "Hello {{ name|nonexistent_filter }}!\n" +
+		"{% for item in items %}{% unknown_tag %}{{ item }}{% endfor %}\n" +
+		"{% if %}yes{% endif %}{{ valid }}"
+
+	// Default: only the first error
+	set := pongo2.NewSet("parse errors", pongo2.MustNewLocalFileSystemLoader(""))
+	_, err := set.FromString(src)
+	var single *pongo2.Error
+	if !errors.As(err, &single) {
+		t.Fatalf("expected an *Error, got %T", err)
+	}
+	mustEqual(t, single.Error(), ".*Line 1 Col 15.*Filter 'nonexistent_filter' does not exist.*")
+
+	set.CollectParseErrors = true
+	_, err = set.FromString(src)
+	list, ok := err.(pongo2.ErrorList)
+	if !ok {
+		t.Fatalf("expected an ErrorList, got %T (%v)", err, err)
+	}
+	if len(list) != 3 {
+		t.Fatalf("expected 3 errors, got %d: %v", len(list), list)
+	}
+	mustEqual(t, list[0].Error(), ".*Line 1 Col 15.*Filter 'nonexistent_filter' does not exist.*")
+	mustEqual(t, list[1].Error(), ".*Line 2 Col 27.*Tag 'unknown_tag' not found.*")
+	mustEqual(t, list[2].Error(), ".*Line 3 Col 4 near .if.*")
+	mustEqual(t, err.Error(), "(?s).*nonexistent_filter.*\n.*unknown_tag.*\n.*")
+
+	// No errors at all
+	tpl, err := set.FromString(`{% for i in items %}{{ i }}{% endfor %}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := tpl.Execute(pongo2.Context{"items": []int{1, 2}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mustEqual(t, out, "12")
+}
+
+func TestErrorJSON(t *testing.T) {
+	// Parse error
+	_, err := pongo2.FromString("Hello\n{{ name|nonexistent }}")
+	var perr *pongo2.Error
+	if !errors.As(err, &perr) {
+		t.Fatalf("expected an *Error, got %T", err)
+	}
+	b, jerr := json.Marshal(perr)
+	if jerr != nil {
+		t.Fatal(jerr)
+	}
+	mustEqual(t, string(b), regexp.QuoteMeta(`{"col":9,"file":"\u003cstring\u003e","line":2,"message":"Filter 'nonexistent' does not exist.","sender":"parser","template":"\u003cstring\u003e"}`))
+
+	// Filter (execution) error
+	tpl, err := pongo2.FromString(`{{ 42|duration }}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = tpl.Execute(nil)
+	if !errors.As(err, &perr) {
+		t.Fatalf("expected an *Error, got %T", err)
+	}
+	fields := perr.Fields()
+	mustEqual(t, fields["sender"].(string), "^filter:duration$")
+	mustEqual(t, fields["message"].(string), "^filter input argument must be of type 'time.Duration'$")
+	mustEqual(t, fmt.Sprintf("%v:%v:%v", fields["file"], fields["line"], fields["col"]), "^<string>:1:7$")
+	mustEqual(t, fields["template"].(string), "^<string>$")
+	b, jerr = json.Marshal(perr)
+	if jerr != nil {
+		t.Fatal(jerr)
+	}
+	mustEqual(t, string(b), regexp.QuoteMeta(`"sender":"filter:duration"`))
+
+	// Nothing set at all
+	b, jerr = json.Marshal(&pongo2.Error{})
+	if jerr != nil {
+		t.Fatal(jerr)
+	}
+	mustEqual(t, string(b), regexp.QuoteMeta(`{"col":0,"file":"","line":0,"message":"","sender":"","template":""}`))
+	mustEqual(t, (&pongo2.Error{}).Error(), regexp.QuoteMeta("[Error] "))
+}
+
+func TestUndefinedBehavior(t *testing.T) {
+	src := "Hello {{ name }}{{ nil_value }}!\n{% for i in items %}{{ i }}{% endfor %} {{ missing.attr }}"
+	ctx := pongo2.Context{"name": "pongo2", "nil_value": nil, "items": []int{1, 2}}
+
+	render := func(set *pongo2.TemplateSet) (string, error) {
+		tpl, err := set.FromString(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tpl.Execute(ctx)
+	}
+
+	set := pongo2.NewSet("undefined", pongo2.MustNewLocalFileSystemLoader(""))
+
+	// Silent (default)
+	out, err := render(set)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mustEqual(t, out, "^Hello pongo2!\n12 $")
+
+	// Error
+	set.UndefinedBehavior = pongo2.UndefinedError
+	_, err = render(set)
+	mustEqual(t, fmt.Sprintf("%v", err), regexp.QuoteMeta("[Error (where: execution) in <string> | Line 2 Col 44 near 'missing'] variable 'missing' is undefined"))
+
+	// Custom
+	set.UndefinedBehavior = pongo2.UndefinedCustom
+	var names []string
+	set.UndefinedHandler = func(name string) (*pongo2.Value, *pongo2.Error) {
+		names = append(names, name)
+		return pongo2.AsValue(map[string]string{"attr": "<" + name + ">"}), nil
+	}
+	out, err = render(set)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mustEqual(t, out, "^Hello pongo2!\n12 &lt;missing&gt;$")
+	mustEqual(t, strings.Join(names, ","), "^missing$")
+
+	set.UndefinedHandler = func(name string) (*pongo2.Value, *pongo2.Error) {
+		return nil, &pongo2.Error{Sender: "undefined", OrigError: fmt.Errorf("no %s", name)}
+	}
+	_, err = render(set)
+	mustEqual(t, fmt.Sprintf("%v", err), regexp.QuoteMeta("[Error (where: undefined) in <string> | Line 2 Col 44 near 'missing'] no missing"))
+}
+
+func TestCycleStatePerExecution(t *testing.T) {
+	tpl, err := pongo2.FromString(`{% for i in items %}{% cycle "odd" "even" as rowclass silent %}{{ rowclass }} {% endfor %}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 100)
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+			out, err := tpl.Execute(pongo2.Context{"items": make([]int, n%4+1)})
+			if err != nil {
+				errs <- err
+				return
+			}
+			expected := []string{"odd ", "odd even ", "odd even odd ", "odd even odd even "}[n%4]
+			if out != expected {
+				errs <- fmt.Errorf("expected %q, got %q", expected, out)
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+func TestExpressionShortCircuit(t *testing.T) {
+	var calls []string
+	err := pongo2.RegisterFilter("zz_record_call", func(in, param *pongo2.Value, bind map[string]any) (*pongo2.Value, *pongo2.Error) {
+		calls = append(calls, in.String())
+		return in, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tpl, err := pongo2.FromString(`{{ active ? ("on"|zz_record_call) : ("off"|zz_record_call) }}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, active := range []bool{true, false} {
+		calls = nil
+		out, err := tpl.Execute(pongo2.Context{"active": active})
+		if err != nil {
+			t.Fatal(err)
+		}
+		mustEqual(t, out+"="+strings.Join(calls, ","), map[bool]string{true: "^on=on$", false: "^off=off$"}[active])
+	}
+
+	// The null-coalescing operator only evaluates the right operand on nil
+	tpl, err = pongo2.FromString(`{{ value ?? ("fallback"|zz_record_call) }}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, value := range []any{"", nil} {
+		calls = nil
+		out, err := tpl.Execute(pongo2.Context{"value": value})
+		if err != nil {
+			t.Fatal(err)
+		}
+		mustEqual(t, out+"="+strings.Join(calls, ","), map[bool]string{true: "^=$", false: "^fallback=fallback$"}[value != nil])
+	}
+}
+
+type shoutTagNode struct {
+	expr   pongo2.IEvaluator
+	suffix string
+}
+
+func (node *shoutTagNode) Execute(ctx *pongo2.ExecutionContext, writer pongo2.TemplateWriter) *pongo2.Error {
+	v, err := node.expr.Evaluate(ctx)
+	if err != nil {
+		return err
+	}
+	writer.WriteString(strings.ToUpper(v.String()) + node.suffix)
+	return nil
+}
+
+func shoutTagParser(suffix string) pongo2.TagParser {
+	return func(doc *pongo2.Parser, start *pongo2.Token, arguments *pongo2.Parser) (pongo2.INodeTag, *pongo2.Error) {
+		expr, err := arguments.ParseExpression()
+		if err != nil {
+			return nil, err
+		}
+		if arguments.Remaining() > 0 {
+			return nil, arguments.Error("Malformed shout-tag arguments.", nil)
+		}
+		return &shoutTagNode{expr: expr, suffix: suffix}, nil
+	}
+}
+
+func TestRegisterTag(t *testing.T) {
+	if pongo2.TagExists("zz_shout") {
+		t.Fatal("tag zz_shout must not exist yet")
+	}
+	if err := pongo2.RegisterTag("zz_shout", shoutTagParser("!")); err != nil {
+		t.Fatal(err)
+	}
+	if !pongo2.TagExists("zz_shout") {
+		t.Fatal("tag zz_shout must exist")
+	}
+	mustEqual(t, pongo2.RegisterTag("zz_shout", shoutTagParser("?")).Error(), "tag with name 'zz_shout' is already registered")
+	mustEqual(t, pongo2.ReplaceTag("zz_doesnotexist", shoutTagParser("?")).Error(), ".*does not exist.*")
+
+	tpl, err := pongo2.FromString(`{% zz_shout name %} {% zz_shout "x"|add:1 %}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := tpl.Execute(pongo2.Context{"name": "flosch"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mustEqual(t, out, "^FLOSCH! X1!$")
+
+	// Replacing a tag only affects templates parsed afterwards
+	if err := pongo2.ReplaceTag("zz_shout", shoutTagParser("?")); err != nil {
+		t.Fatal(err)
+	}
+	out, err = pongo2.RenderTemplateString(`{% zz_shout name %}`, pongo2.Context{"name": "flosch"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mustEqual(t, out, `^FLOSCH\?$`)
+	out, err = tpl.Execute(pongo2.Context{"name": "flosch"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mustEqual(t, out, "^FLOSCH! X1!$")
+
+	// The registry is safe for concurrent use
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			if err := pongo2.RegisterTag(fmt.Sprintf("zz_shout_%d", i), shoutTagParser("")); err != nil {
+				t.Error(err)
+			}
+		}(i)
+		go func() {
+			defer wg.Done()
+			if !pongo2.TagExists("zz_shout") {
+				t.Error("tag zz_shout must exist")
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestListTags(t *testing.T) {
+	if err := pongo2.RegisterTag("zz_list_test_tag", shoutTagParser("")); err != nil {
+		t.Fatal(err)
+	}
+
+	names := pongo2.ListTags()
+	if !sort.StringsAreSorted(names) {
+		t.Fatalf("ListTags() is not sorted: %v", names)
+	}
+	for _, name := range []string{"block", "for", "if", "zz_list_test_tag"} {
+		idx := sort.SearchStrings(names, name)
+		if idx >= len(names) || names[idx] != name {
+			t.Errorf("%s not found in %v", name, names)
+		}
+	}
+}
+
+type variadicHelpers struct{}
+
+func (h variadicHelpers) Join(sep string, parts ...string) string {
+	return strings.Join(parts, sep)
+}
+
+func (h *variadicHelpers) Describe(prefix string, items ...fmt.Stringer) string {
+	descriptions := []string{prefix}
+	for _, item := range items {
+		if item == nil {
+			descriptions = append(descriptions, "nil")
+			continue
+		}
+		descriptions = append(descriptions, item.String())
+	}
+	return strings.Join(descriptions, " ")
+}
+
+func TestVariadicMethodCalls(t *testing.T) {
+	ctx := pongo2.Context{
+		"h":        variadicHelpers{},
+		"hp":       &variadicHelpers{},
+		"parts":    []string{"x", "y"},
+		"duration": 90 * time.Second,
+	}
+
+	tests := []struct {
+		tpl, want string
+	}{
+		{`{{ h.Join("-") }}`, ""},
+		{`{{ h.Join("-", "a") }}`, "a"},
+		{`{{ h.Join("-", "a", "b", "c") }}`, "a-b-c"},
+		{`{{ hp.Join(", ", parts.0, parts.1) }}`, "x, y"},
+		{`{{ hp.Describe("took") }}`, "took"},
+		{`{{ hp.Describe("took", duration, nil) }}`, "took 1m30s nil"},
+	}
+	for _, test := range tests {
+		out, err := pongo2.RenderTemplateString(test.tpl, ctx)
+		if err != nil {
+			t.Fatalf("%s: %v", test.tpl, err)
+		}
+		if out != test.want {
+			t.Errorf("%s: got %q, want %q", test.tpl, out, test.want)
+		}
+	}
+
+	// Every trailing argument must be assignable to the variadic type
+	for tpl, want := range map[string]string{
+		`{{ h.Join("-", "a", 1) }}`:     `function variadic input argument of 'h.Join' must be of type string or \*pongo2.Value \(not int\)`,
+		`{{ h.Join(1, "a") }}`:          `function input argument 0 of 'h.Join' must be of type string or \*pongo2.Value \(not int\)`,
+		`{{ hp.Describe("took", 42) }}`: `function variadic input argument of 'hp.Describe' must be of type fmt.Stringer or \*pongo2.Value \(not int\)`,
+		`{{ h.Join("-", "a", nil) }}`:   `function variadic input argument of 'h.Join' must be of type string or \*pongo2.Value \(not <nil>\)`,
+	} {
+		_, err := pongo2.RenderTemplateString(tpl, ctx)
+		mustEqual(t, fmt.Sprintf("%v", err), want)
+	}
+}
+
+type buttonOptions struct {
+	Primary bool
+	Label   string `pongo2:"label"`
+	Size    *pongo2.Value
+	Extra   any
+	hidden  bool
+}
+
+func TestFunctionCallKeywordArguments(t *testing.T) {
+	ctx := pongo2.Context{
+		"button": func(name string, opts buttonOptions) string {
+			return fmt.Sprintf("%s:%v:%q:%v:%v", name, opts.Primary, opts.Label, opts.Size, opts.Extra)
+		},
+		"buttonp": func(opts *buttonOptions) string {
+			return fmt.Sprintf("%v:%q", opts.Primary, opts.Label)
+		},
+		"attrs": func(tag string, attrs map[string]any) string {
+			keys := make([]string, 0, len(attrs))
+			for key := range attrs {
+				keys = append(keys, fmt.Sprintf("%s=%v", key, attrs[key]))
+			}
+			sort.Strings(keys)
+			return tag + " " + strings.Join(keys, " ")
+		},
+		"plain": func(a int) int { return a },
+		"size":  3,
+	}
+
+	tests := []struct {
+		tpl, want string
+	}{
+		{`{{ button("save", primary=true, label="Save it") }}`, `save:true:"Save it":<nil>:<nil>`},
+		{`{{ button("save", size=size|add:1, extra=nil) }}`, `save:false:"":4:<nil>`},
+		{`{{ button("save", label="x", PRIMARY=true, extra=size) }}`, `save:true:"x":<nil>:3`},
+		{`{{ button("save") }}`, `save:false:"":<nil>:<nil>`},
+		{`{{ buttonp(primary=true, label="p") }}`, `true:"p"`},
+		{`{{ attrs("a", href="/", n=size, x=nil) }}`, `a href=/ n=3 x=<nil>`},
+		{`{{ attrs("br") }}`, `br `},
+	}
+	for _, test := range tests {
+		out, err := pongo2.RenderTemplateString("{% autoescape off %}"+test.tpl+"{% endautoescape %}", ctx)
+		if err != nil {
+			t.Fatalf("%s: %v", test.tpl, err)
+		}
+		if out != test.want {
+			t.Errorf("%s: got %q, want %q", test.tpl, out, test.want)
+		}
+	}
+
+	for tpl, want := range map[string]string{
+		`{{ button("save", color="red") }}`:   `'button' has no keyword argument 'color'`,
+		`{{ button("save", hidden=true) }}`:   `'button' has no keyword argument 'hidden'`,
+		`{{ button("save", Label="x") }}`:     `'button' has no keyword argument 'Label'`,
+		`{{ button("save", primary="yes") }}`: `keyword argument 'primary' of 'button' must be of type bool or \*pongo2.Value \(not string\)`,
+		`{{ button("save", label=nil) }}`:     `keyword argument 'label' of 'button' must be of type string or \*pongo2.Value \(not <nil>\)`,
+		`{{ plain(1, x=2) }}`:                 `'plain' does not take keyword arguments`,
+		`{{ size(x=2) }}`:                     `'size' does not take keyword arguments`,
+	} {
+		_, err := pongo2.RenderTemplateString(tpl, ctx)
+		mustEqual(t, fmt.Sprintf("%v", err), want)
+	}
+}
+
 func TestImplicitExecCtx(t *testing.T) {
 	tpl, err := pongo2.FromString("{{ ImplicitExec }}")
 	if err != nil {
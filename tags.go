@@ -1,26 +1,35 @@
 package pongo2
 
-/* Incomplete:
-   -----------
+/* Configured on the TemplateSet:
+   ------------------------------
 
-   verbatim (only the "name" argument is missing for verbatim)
+   cache (the fragments are stored in the set's CacheBackend)
+   trans, blocktrans (the messages are translated by the set's Translator)
+   url (the URLs are built by the set's URLResolver)
 
-   Reconsideration:
-   ----------------
+   Notes:
+   ------
 
-   debug (reason: not sure what to output yet)
-   regroup / Grouping on other properties (reason: maybe too python-specific; not sure how useful this would be in Go)
+   debug (dumps the given expression or, without one, the whole context)
+   regroup (like in Django, only consecutive items form a group)
+
+   Not part of Django:
+   -------------------
+
+   break, continue (within for-loops)
+   capture, collapse, include_raw, switch
 
    Following built-in tags wont be added:
    --------------------------------------
 
    csrf_token (reason: web-framework specific)
    load (reason: python-specific)
-   url (reason: web-framework specific)
 */
 
 import (
 	"fmt"
+	"sort"
+	"sync"
 )
 
 type INodeTag interface {
@@ -47,38 +56,58 @@ type tag struct {
 	parser TagParser
 }
 
-var tags map[string]*tag
+// var tags map[string]*tag
+var tags *sync.Map
 
 func init() {
-	tags = make(map[string]*tag)
+	tags = new(sync.Map)
+}
+
+// TagExists returns true if the given tag is already registered
+func TagExists(name string) bool {
+	_, existing := tags.Load(name)
+	return existing
+}
+
+// ListTags returns the names of all registered tags in sorted order.
+func ListTags() []string {
+	var names []string
+	tags.Range(func(key, value any) bool {
+		names = append(names, key.(string))
+		return true
+	})
+	sort.Strings(names)
+	return names
 }
 
 // Registers a new tag. You usually want to call this
 // function in the tag's init() function:
 // http://golang.org/doc/effective_go.html#init
+//
+// The tag registry is safe for concurrent use; templates parsed after a tag
+// has been registered can use it.
 func RegisterTag(name string, parserFn TagParser) error {
-	_, existing := tags[name]
-	if existing {
-		return fmt.Errorf("tag with name '%s' is already registered", name)
-	}
-	tags[name] = &tag{
+	_, existing := tags.LoadOrStore(name, &tag{
 		name:   name,
 		parser: parserFn,
+	})
+	if existing {
+		return fmt.Errorf("tag with name '%s' is already registered", name)
 	}
 	return nil
 }
 
 // Replaces an already registered tag with a new implementation. Use this
 // function with caution since it allows you to change existing tag behaviour.
+// Already parsed templates keep using the former implementation.
 func ReplaceTag(name string, parserFn TagParser) error {
-	_, existing := tags[name]
-	if !existing {
+	if !TagExists(name) {
 		return fmt.Errorf("tag with name '%s' does not exist (therefore cannot be overridden)", name)
 	}
-	tags[name] = &tag{
+	tags.Store(name, &tag{
 		name:   name,
 		parser: parserFn,
-	}
+	})
 	return nil
 }
 
@@ -93,14 +122,14 @@ func (p *Parser) parseTagElement() (INodeTag, *Error) {
 	}
 
 	// Check for the existing tag
-	tag, exists := tags[tokenName.Val]
+	storedTag, exists := tags.Load(tokenName.Val)
 	if !exists {
 		// Does not exists
 		return nil, p.Error(fmt.Sprintf("Tag '%s' not found (or beginning tag not provided)", tokenName.Val), tokenName)
 	}
 
 	// Check sandbox tag restriction
-	if _, isBanned := p.template.set.bannedTags[tokenName.Val]; isBanned {
+	if p.template.set.isTagBanned(tokenName.Val) {
 		return nil, p.Error(fmt.Sprintf("Usage of tag '%s' is not allowed (sandbox restriction active).", tokenName.Val), tokenName)
 	}
 
@@ -126,5 +155,5 @@ func (p *Parser) parseTagElement() (INodeTag, *Error) {
 
 	p.template.level++
 	defer func() { p.template.level-- }()
-	return tag.parser(p, tokenName, argParser)
+	return storedTag.(*tag).parser(p, tokenName, argParser)
 }
@@ -6,17 +6,15 @@ type tagAutoescapeNode struct {
 }
 
 func (node *tagAutoescapeNode) Execute(ctx *ExecutionContext, writer TemplateWriter) *Error {
+	// Restore the outer setting on any exit (including a break or continue
+	// leaving the block early), so autoescape-tags nest correctly
 	old := ctx.Autoescape
 	ctx.Autoescape = node.autoescape
+	defer func() {
+		ctx.Autoescape = old
+	}()
 
-	err := node.wrapper.Execute(ctx, writer)
-	if err != nil {
-		return err
-	}
-
-	ctx.Autoescape = old
-
-	return nil
+	return node.wrapper.Execute(ctx, writer)
 }
 
 func tagAutoescapeParser(doc *Parser, start *Token, arguments *Parser) (INodeTag, *Error) {
@@ -42,7 +42,7 @@ func (node *tagBlockNode) Execute(ctx *ExecutionContext, writer TemplateWriter)
 	ctx.Private["block"] = tagBlockInformation{
 		ctx:      ctx,
 		wrappers: blockWrappers[0 : lenBlockWrappers-1],
-	}
+	}.context()
 	err := blockWrapper.Execute(ctx, writer)
 	if err != nil {
 		return err
@@ -56,6 +56,15 @@ type tagBlockInformation struct {
 	wrappers []*NodeWrapper
 }
 
+// context returns what's available as `block` within a block: the parent's
+// content as `block.super` (Django's spelling) and `block.Super`.
+func (t tagBlockInformation) context() map[string]any {
+	return map[string]any{
+		"super": t.Super,
+		"Super": t.Super,
+	}
+}
+
 func (t tagBlockInformation) Super() (*Value, error) {
 	lenWrappers := len(t.wrappers)
 
@@ -67,11 +76,11 @@ func (t tagBlockInformation) Super() (*Value, error) {
 	superCtx.Private["block"] = tagBlockInformation{
 		ctx:      t.ctx,
 		wrappers: t.wrappers[0 : lenWrappers-1],
-	}
+	}.context()
 
 	blockWrapper := t.wrappers[lenWrappers-1]
 	buf := bytes.NewBufferString("")
-	err := blockWrapper.Execute(superCtx, &templateWriter{buf})
+	err := blockWrapper.Execute(superCtx, &templateWriter{w: buf})
 	if err != nil {
 		return AsSafeValue(""), err
 	}
@@ -92,7 +101,18 @@ func tagBlockParser(doc *Parser, start *Token, arguments *Parser) (INodeTag, *Er
 		return nil, arguments.Error("Tag 'block' takes exactly 1 argument (an identifier).", nil)
 	}
 
+	tpl := doc.template
+	if tpl == nil {
+		panic("internal error: tpl == nil")
+	}
+
+	parentBlock := ""
+	if len(tpl.blockNames) > 0 {
+		parentBlock = tpl.blockNames[len(tpl.blockNames)-1]
+	}
+	tpl.blockNames = append(tpl.blockNames, nameToken.Val)
 	wrapper, endtagargs, err := doc.WrapUntilTag("endblock")
+	tpl.blockNames = tpl.blockNames[:len(tpl.blockNames)-1]
 	if err != nil {
 		return nil, err
 	}
@@ -110,13 +130,15 @@ func tagBlockParser(doc *Parser, start *Token, arguments *Parser) (INodeTag, *Er
 		}
 	}
 
-	tpl := doc.template
-	if tpl == nil {
-		panic("internal error: tpl == nil")
-	}
 	_, hasBlock := tpl.blocks[nameToken.Val]
 	if !hasBlock {
 		tpl.blocks[nameToken.Val] = wrapper
+		tpl.blockInfos = append(tpl.blockInfos, BlockInfo{
+			Name:   nameToken.Val,
+			Line:   start.Line,
+			Col:    start.Col,
+			Parent: parentBlock,
+		})
 	} else {
 		return nil, arguments.Error(fmt.Sprintf("Block named '%s' already defined", nameToken.Val), nil)
 	}
@@ -0,0 +1,68 @@
+package pongo2
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+// errLoopBreak and errLoopContinue are being returned by the break- and
+// continue-tags to stop the current iteration of the innermost for-loop.
+var (
+	errLoopBreak = &Error{
+		Sender:    "tag:break",
+		OrigError: errors.New("'break' used outside of a for-loop"),
+	}
+	errLoopContinue = &Error{
+		Sender:    "tag:continue",
+		OrigError: errors.New("'continue' used outside of a for-loop"),
+	}
+)
+
+// isLoopControl reports whether err has been returned by a break- or continue-tag.
+func isLoopControl(err *Error) bool {
+	return err == errLoopBreak || err == errLoopContinue
+}
+
+// executeBuffered renders wrapper into a buffer and returns its content. A
+// break or continue is returned as loopErr together with the content rendered
+// until then; after processing the content, the caller returns loopErr, which
+// passes a break or continue on to the surrounding loop.
+func executeBuffered(wrapper *NodeWrapper, ctx *ExecutionContext) (content string, loopErr *Error, err *Error) {
+	b := bytes.NewBuffer(make([]byte, 0, 1024)) // 1 KiB
+
+	if err := wrapper.Execute(ctx, b); err != nil {
+		if !isLoopControl(err) {
+			return "", nil, err
+		}
+		loopErr = err
+	}
+	return b.String(), loopErr, nil
+}
+
+type tagBreakNode struct {
+	err *Error
+}
+
+func (node *tagBreakNode) Execute(ctx *ExecutionContext, writer TemplateWriter) *Error {
+	return node.err
+}
+
+func tagBreakParser(doc *Parser, start *Token, arguments *Parser) (INodeTag, *Error) {
+	if doc.loopDepth == 0 {
+		return nil, doc.Error(fmt.Sprintf("Tag '%s' is only allowed within a for-loop.", start.Val), start)
+	}
+	if arguments.Count() > 0 {
+		return nil, arguments.Error(fmt.Sprintf("Tag '%s' does not take any argument.", start.Val), nil)
+	}
+
+	if start.Val == "continue" {
+		return &tagBreakNode{err: errLoopContinue}, nil
+	}
+	return &tagBreakNode{err: errLoopBreak}, nil
+}
+
+func init() {
+	RegisterTag("break", tagBreakParser)
+	RegisterTag("continue", tagBreakParser)
+}
@@ -0,0 +1,105 @@
+package pongo2
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+type tagCacheNode struct {
+	position *Token
+	ttl      IEvaluator
+	name     string
+	varyOn   []IEvaluator
+	wrapper  *NodeWrapper
+}
+
+func (node *tagCacheNode) Execute(ctx *ExecutionContext, writer TemplateWriter) *Error {
+	ttl, err := node.ttl.Evaluate(ctx)
+	if err != nil {
+		return err
+	}
+	if !ttl.IsNumber() {
+		return ctx.Error(fmt.Sprintf("Cache timeout must be a number (in seconds), got '%s'.", ttl.String()), node.position)
+	}
+
+	// The key consists of the fragment's name and all vary-on values; the
+	// values are escaped so that the separator can't appear within them.
+	var key strings.Builder
+	key.WriteString("pongo2.cache.")
+	key.WriteString(node.name)
+	for _, varyExpr := range node.varyOn {
+		vary, err := varyExpr.Evaluate(ctx)
+		if err != nil {
+			return err
+		}
+		key.WriteByte(':')
+		key.WriteString(url.QueryEscape(vary.String()))
+	}
+
+	backend := ctx.template.set.CacheBackend
+	if backend == nil {
+		// No caching at all
+		return node.wrapper.Execute(ctx, writer)
+	}
+
+	if fragment, has := backend.Get(key.String()); has {
+		writer.WriteString(fragment)
+		return nil
+	}
+
+	b := bytes.NewBuffer(make([]byte, 0, 1024)) // 1 KiB
+	if err := node.wrapper.Execute(ctx, b); err != nil {
+		if isLoopControl(err) {
+			// The output rendered until a break or continue is kept, but it's
+			// incomplete and therefore not cached
+			writer.Write(b.Bytes())
+		}
+		return err
+	}
+	backend.Set(key.String(), b.String(), time.Duration(ttl.Float()*float64(time.Second)))
+	writer.Write(b.Bytes())
+
+	return nil
+}
+
+// {% cache ttl fragment_name [vary_on ...] %}...{% endcache %}
+func tagCacheParser(doc *Parser, start *Token, arguments *Parser) (INodeTag, *Error) {
+	cacheNode := &tagCacheNode{
+		position: start,
+	}
+
+	ttl, err := arguments.ParseExpression()
+	if err != nil {
+		return nil, err
+	}
+	cacheNode.ttl = ttl
+
+	nameToken := arguments.MatchType(TokenIdentifier)
+	if nameToken == nil {
+		return nil, arguments.Error("Expected a fragment name (identifier) after the cache timeout.", nil)
+	}
+	cacheNode.name = nameToken.Val
+
+	for arguments.Remaining() > 0 {
+		varyExpr, err := arguments.ParseExpression()
+		if err != nil {
+			return nil, err
+		}
+		cacheNode.varyOn = append(cacheNode.varyOn, varyExpr)
+	}
+
+	wrapper, _, err := doc.WrapUntilTag("endcache")
+	if err != nil {
+		return nil, err
+	}
+	cacheNode.wrapper = wrapper
+
+	return cacheNode, nil
+}
+
+func init() {
+	RegisterTag("cache", tagCacheParser)
+}
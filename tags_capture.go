@@ -0,0 +1,55 @@
+package pongo2
+
+type tagCaptureNode struct {
+	name    string
+	wrapper *NodeWrapper
+}
+
+func (node *tagCaptureNode) Execute(ctx *ExecutionContext, writer TemplateWriter) *Error {
+	content, loopErr, err := executeBuffered(node.wrapper, ctx)
+	if err != nil {
+		return err
+	}
+
+	// The body has been escaped while rendering already
+	ctx.Private[node.name] = AsSafeValue(content)
+
+	return loopErr
+}
+
+// tagCaptureParser parses
+//
+//	{% capture as name %}...{% endcapture %}
+//
+// which renders the body into the variable name instead of the output.
+func tagCaptureParser(doc *Parser, start *Token, arguments *Parser) (INodeTag, *Error) {
+	captureNode := &tagCaptureNode{}
+
+	if arguments.Match(TokenKeyword, "as") == nil {
+		return nil, arguments.Error("Expected 'as'.", nil)
+	}
+	nameToken := arguments.MatchType(TokenIdentifier)
+	if nameToken == nil {
+		return nil, arguments.Error("Expected an identifier after 'as'.", nil)
+	}
+	captureNode.name = nameToken.Val
+
+	if arguments.Remaining() > 0 {
+		return nil, arguments.Error("Malformed capture-tag arguments.", nil)
+	}
+
+	wrapper, endargs, err := doc.WrapUntilTag("endcapture")
+	if err != nil {
+		return nil, err
+	}
+	if endargs.Count() > 0 {
+		return nil, endargs.Error("Arguments not allowed here.", nil)
+	}
+	captureNode.wrapper = wrapper
+
+	return captureNode, nil
+}
+
+func init() {
+	RegisterTag("capture", tagCaptureParser)
+}
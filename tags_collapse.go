@@ -0,0 +1,108 @@
+package pongo2
+
+import (
+	"strings"
+	"unicode"
+)
+
+type tagCollapseNode struct {
+	wrapper     *NodeWrapper
+	replacement IEvaluator
+}
+
+// tagCollapseWhitespace replaces every run of whitespace in s by replacement.
+// Whitespace within single or double quotes is left untouched. A quote only
+// starts at a word boundary (so the apostrophe in "it's" doesn't) and ends at
+// the line's end at the latest.
+func tagCollapseWhitespace(s, replacement string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+
+	var quote rune // the quote we're currently in (0 if none)
+	var prev rune  // the previous rune (0 at the start)
+	escaped := false
+	inWhitespace := false
+
+	for _, r := range s {
+		if quote != 0 && r == '\n' {
+			quote = 0
+			escaped = false
+		}
+		atWordBoundary := !unicode.IsLetter(prev) && !unicode.IsDigit(prev)
+		prev = r
+
+		if quote == 0 && unicode.IsSpace(r) {
+			if !inWhitespace {
+				b.WriteString(replacement)
+				inWhitespace = true
+			}
+			continue
+		}
+		inWhitespace = false
+		b.WriteRune(r)
+
+		switch {
+		case quote == 0:
+			if (r == '"' || r == '\'') && atWordBoundary {
+				quote = r
+			}
+		case escaped:
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == quote:
+			quote = 0
+		}
+	}
+
+	return b.String()
+}
+
+func (node *tagCollapseNode) Execute(ctx *ExecutionContext, writer TemplateWriter) *Error {
+	replacement := " "
+	if node.replacement != nil {
+		val, err := node.replacement.Evaluate(ctx)
+		if err != nil {
+			return err
+		}
+		replacement = val.String()
+	}
+
+	content, loopErr, err := executeBuffered(node.wrapper, ctx)
+	if err != nil {
+		return err
+	}
+
+	writer.WriteString(tagCollapseWhitespace(content, replacement))
+
+	return loopErr
+}
+
+// {% collapse [replacement] %}...{% endcollapse %}
+func tagCollapseParser(doc *Parser, start *Token, arguments *Parser) (INodeTag, *Error) {
+	collapseNode := &tagCollapseNode{}
+
+	wrapper, _, err := doc.WrapUntilTag("endcollapse")
+	if err != nil {
+		return nil, err
+	}
+	collapseNode.wrapper = wrapper
+
+	if arguments.Remaining() > 0 {
+		replacement, err := arguments.ParseExpression()
+		if err != nil {
+			return nil, err
+		}
+		collapseNode.replacement = replacement
+	}
+
+	if arguments.Remaining() > 0 {
+		return nil, arguments.Error("Malformed collapse-tag arguments.", nil)
+	}
+
+	return collapseNode, nil
+}
+
+func init() {
+	RegisterTag("collapse", tagCollapseParser)
+}
@@ -2,13 +2,20 @@ package pongo2
 
 type tagCycleValue struct {
 	node  *tagCycleNode
+	state *tagCycleState
 	value *Value
 }
 
+// tagCycleState is the per-execution position of a cycle-tag; it's kept in
+// the execution context so concurrent executions don't interfere and every
+// run of the enclosing loop starts over with the first argument.
+type tagCycleState struct {
+	idx int
+}
+
 type tagCycleNode struct {
 	position *Token
 	args     []IEvaluator
-	idx      int
 	asName   string
 	silent   bool
 }
@@ -17,9 +24,21 @@ func (cv *tagCycleValue) String() string {
 	return cv.value.String()
 }
 
+func (node *tagCycleNode) next(state *tagCycleState) IEvaluator {
+	item := node.args[state.idx%len(node.args)]
+	state.idx++
+	return item
+}
+
 func (node *tagCycleNode) Execute(ctx *ExecutionContext, writer TemplateWriter) *Error {
-	item := node.args[node.idx%len(node.args)]
-	node.idx++
+	var state *tagCycleState
+	if s, has := ctx.tagState(node); has {
+		state = s.(*tagCycleState)
+	} else {
+		state = &tagCycleState{}
+		ctx.setTagState(node, state)
+	}
+	item := node.next(state)
 
 	val, err := item.Evaluate(ctx)
 	if err != nil {
@@ -31,8 +50,7 @@ func (node *tagCycleNode) Execute(ctx *ExecutionContext, writer TemplateWriter)
 		// {% cycle cycleitem %}
 
 		// Update the cycle value with next value
-		item := t.node.args[t.node.idx%len(t.node.args)]
-		t.node.idx++
+		item := t.node.next(t.state)
 
 		val, err := item.Evaluate(ctx)
 		if err != nil {
@@ -49,6 +67,7 @@ func (node *tagCycleNode) Execute(ctx *ExecutionContext, writer TemplateWriter)
 
 		cycleValue := &tagCycleValue{
 			node:  node,
+			state: state,
 			value: val,
 		}
 
@@ -0,0 +1,166 @@
+package pongo2
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Maximum nesting depth the debug-tag dumps; protects against cyclic values
+const tagDebugMaxDepth = 10
+
+type tagDebugNode struct {
+	position *Token
+	expr     IEvaluator
+}
+
+func (node *tagDebugNode) Execute(ctx *ExecutionContext, writer TemplateWriter) *Error {
+	if !ctx.template.set.Debug {
+		return nil
+	}
+
+	var b strings.Builder
+	if node.expr == nil {
+		// Dump the whole context
+		vars := make(map[string]any, len(ctx.Public)+len(ctx.Private))
+		for key, value := range ctx.Public {
+			vars[key] = value
+		}
+		for key, value := range ctx.Private {
+			vars[key] = value
+		}
+		tagDebugDump(&b, reflect.ValueOf(vars), 0)
+	} else {
+		val, err := node.expr.Evaluate(ctx)
+		if err != nil {
+			return err
+		}
+		tagDebugDump(&b, reflect.ValueOf(val.Interface()), 0)
+	}
+
+	out := AsValue(b.String())
+	if ctx.Autoescape {
+		var err *Error
+		out, err = filterEscape(out, nil, ctx.Public)
+		if err != nil {
+			return err
+		}
+	}
+	writer.WriteString(out.String())
+
+	return nil
+}
+
+// tagDebugDump writes a human-readable representation of rv including its
+// type; structs are dumped with their exported fields, maps ordered by key.
+func tagDebugDump(b *strings.Builder, rv reflect.Value, depth int) {
+	if !rv.IsValid() {
+		b.WriteString("nil")
+		return
+	}
+	if depth > tagDebugMaxDepth {
+		b.WriteString("...")
+		return
+	}
+
+	indent := strings.Repeat("    ", depth+1)
+	closingIndent := strings.Repeat("    ", depth)
+
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if rv.IsNil() {
+			fmt.Fprintf(b, "%s(nil)", rv.Type())
+			return
+		}
+		if rv.Kind() == reflect.Ptr {
+			b.WriteString("&")
+		}
+		tagDebugDump(b, rv.Elem(), depth)
+	case reflect.Struct:
+		fmt.Fprintf(b, "%s{", rv.Type())
+		exported := 0
+		for i := 0; i < rv.NumField(); i++ {
+			field := rv.Type().Field(i)
+			if field.PkgPath != "" {
+				// unexported
+				continue
+			}
+			exported++
+			fmt.Fprintf(b, "\n%s%s: ", indent, field.Name)
+			tagDebugDump(b, rv.Field(i), depth+1)
+			b.WriteString(",")
+		}
+		if exported > 0 {
+			b.WriteString("\n" + closingIndent)
+		}
+		b.WriteString("}")
+	case reflect.Map:
+		fmt.Fprintf(b, "%s{", rv.Type())
+		keys := rv.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
+		})
+		for _, key := range keys {
+			b.WriteString("\n" + indent)
+			tagDebugDump(b, key, depth+1)
+			b.WriteString(": ")
+			tagDebugDump(b, rv.MapIndex(key), depth+1)
+			b.WriteString(",")
+		}
+		if len(keys) > 0 {
+			b.WriteString("\n" + closingIndent)
+		}
+		b.WriteString("}")
+	case reflect.Slice, reflect.Array:
+		if rv.Kind() == reflect.Slice && rv.IsNil() {
+			fmt.Fprintf(b, "%s(nil)", rv.Type())
+			return
+		}
+		fmt.Fprintf(b, "%s{", rv.Type())
+		for i := 0; i < rv.Len(); i++ {
+			b.WriteString("\n" + indent)
+			tagDebugDump(b, rv.Index(i), depth+1)
+			b.WriteString(",")
+		}
+		if rv.Len() > 0 {
+			b.WriteString("\n" + closingIndent)
+		}
+		b.WriteString("}")
+	case reflect.String:
+		fmt.Fprintf(b, "%q", rv.String())
+	case reflect.Func, reflect.Chan:
+		fmt.Fprintf(b, "%s", rv.Type())
+	default:
+		if rv.CanInterface() {
+			fmt.Fprintf(b, "%s(%v)", rv.Type(), rv.Interface())
+		} else {
+			fmt.Fprintf(b, "%s(%v)", rv.Type(), rv)
+		}
+	}
+}
+
+// {% debug [expression] %}
+func tagDebugParser(doc *Parser, start *Token, arguments *Parser) (INodeTag, *Error) {
+	debugNode := &tagDebugNode{
+		position: start,
+	}
+
+	if arguments.Remaining() > 0 {
+		expr, err := arguments.ParseExpression()
+		if err != nil {
+			return nil, err
+		}
+		debugNode.expr = expr
+	}
+
+	if arguments.Remaining() > 0 {
+		return nil, arguments.Error("Malformed debug-tag arguments.", nil)
+	}
+
+	return debugNode, nil
+}
+
+func init() {
+	RegisterTag("debug", tagDebugParser)
+}
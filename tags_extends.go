@@ -29,7 +29,7 @@ func tagExtendsParser(doc *Parser, start *Token, arguments *Parser) (INodeTag, *
 		// Parse the parent
 		parentTemplate, err := doc.template.set.FromFile(parentFilename)
 		if err != nil {
-			return nil, err.(*Error)
+			return nil, asError(err)
 		}
 
 		// Keep track of things
@@ -1,49 +1,52 @@
 package pongo2
 
-import (
-	"bytes"
-)
+import "fmt"
 
 type nodeFilterCall struct {
-	name      string
-	paramExpr IEvaluator
+	name       string
+	parameters []IEvaluator
 }
 
 type tagFilterNode struct {
 	position    *Token
 	bodyWrapper *NodeWrapper
 	filterChain []*nodeFilterCall
+	asName      string
 }
 
 func (node *tagFilterNode) Execute(ctx *ExecutionContext, writer TemplateWriter) *Error {
-	temp := bytes.NewBuffer(make([]byte, 0, 1024)) // 1 KiB size
-
-	err := node.bodyWrapper.Execute(ctx, temp)
+	content, loopErr, err := executeBuffered(node.bodyWrapper, ctx)
 	if err != nil {
 		return err
 	}
 
-	value := AsValue(temp.String())
+	value := AsValue(content)
 
 	for _, call := range node.filterChain {
-		var param *Value
-		if call.paramExpr != nil {
-			param, err = call.paramExpr.Evaluate(ctx)
-			if err != nil {
-				return err
-			}
-		} else {
-			param = AsValue(nil)
+		param, err := evaluateFilterArguments(ctx, call.parameters)
+		if err != nil {
+			return err
 		}
-		value, err = ApplyFilter(call.name, value, param, ctx.Public)
+		storedFunc, exists := filters.Load(call.name)
+		if !exists {
+			return ctx.Error(fmt.Sprintf("Filter '%s' does not exist.", call.name), node.position)
+		}
+		filterFunc, _ := storedFunc.(FilterFunction)
+		contextFilterFunc, _ := storedFunc.(ContextFilterFunction)
+		value, err = executeFilter(ctx, call.name, filterFunc, contextFilterFunc, value, param)
 		if err != nil {
 			return ctx.Error(err.Error(), node.position)
 		}
 	}
 
-	writer.WriteString(value.String())
+	if node.asName != "" {
+		// Like the printed output, the result isn't escaped (again)
+		ctx.Private[node.asName] = AsSafeValue(value.Interface())
+	} else {
+		writer.WriteString(value.String())
+	}
 
-	return nil
+	return loopErr
 }
 
 func tagFilterParser(doc *Parser, start *Token, arguments *Parser) (INodeTag, *Error) {
@@ -66,14 +69,19 @@ func tagFilterParser(doc *Parser, start *Token, arguments *Parser) (INodeTag, *E
 		}
 		filterCall.name = nameToken.Val
 
+		// Check sandbox filter restriction
+		if doc.template.set.isFilterBanned(nameToken.Val) {
+			return nil, arguments.Error(fmt.Sprintf("Usage of filter '%s' is not allowed (sandbox restriction active).", nameToken.Val), nameToken)
+		}
+
 		if arguments.MatchOne(TokenSymbol, ":") != nil {
-			// Filter parameter
+			// Filter parameters
 			// NOTICE: we can't use ParseExpression() here, because it would parse the next filter "|..." as well in the argument list
-			expr, err := arguments.parseVariableOrLiteral()
+			parameters, err := arguments.parseFilterArguments()
 			if err != nil {
 				return nil, err
 			}
-			filterCall.paramExpr = expr
+			filterCall.parameters = parameters
 		}
 
 		filterNode.filterChain = append(filterNode.filterChain, filterCall)
@@ -83,6 +91,15 @@ func tagFilterParser(doc *Parser, start *Token, arguments *Parser) (INodeTag, *E
 		}
 	}
 
+	// Optionally assign the result instead of printing it
+	if arguments.Match(TokenKeyword, "as") != nil {
+		nameToken := arguments.MatchType(TokenIdentifier)
+		if nameToken == nil {
+			return nil, arguments.Error("Expected an identifier after 'as'.", nil)
+		}
+		filterNode.asName = nameToken.Val
+	}
+
 	if arguments.Remaining() > 0 {
 		return nil, arguments.Error("Malformed filter-tag arguments.", nil)
 	}
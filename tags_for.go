@@ -44,8 +44,19 @@ func (node *tagForNode) Execute(ctx *ExecutionContext, writer TemplateWriter) (f
 		return err
 	}
 
-	obj.IterateOrder(func(idx, count int, key, value *Value) bool {
+	// Receiving from a channel is aborted once the execution is cancelled
+	var done <-chan struct{}
+	var doneErr func() error
+	if c := forCtx.cancellation; c != nil {
+		done, doneErr = c.goctx.Done(), c.goctx.Err
+	}
+
+	iterErr := obj.iterateOrder(done, doneErr, func(idx, count int, key, value *Value) bool {
 		// There's something to iterate over (correct type and at least 1 item)
+		if err := forCtx.checkCancellation(); err != nil {
+			forError = err
+			return false
+		}
 
 		// Update loop infos and public context
 		forCtx.Private[node.key] = key
@@ -65,20 +76,28 @@ func (node *tagForNode) Execute(ctx *ExecutionContext, writer TemplateWriter) (f
 
 		// Render elements with updated context
 		err := node.bodyWrapper.Execute(forCtx, writer)
-		if err != nil {
+		switch err {
+		case nil, errLoopContinue:
+			return true
+		case errLoopBreak:
+			return false
+		default:
 			forError = err
 			return false
 		}
-		return true
 	}, func() {
-		// Nothing to iterate over (maybe wrong type or no items)
+		// Nothing to iterate over (maybe wrong type or no items); the
+		// empty-block is rendered outside of the loop (without forloop).
 		if node.emptyWrapper != nil {
-			err := node.emptyWrapper.Execute(forCtx, writer)
+			err := node.emptyWrapper.Execute(ctx, writer)
 			if err != nil {
 				forError = err
 			}
 		}
 	}, node.reversed, node.sorted)
+	if iterErr != nil {
+		return ctx.OrigError(iterErr, nil)
+	}
 
 	return forError
 }
@@ -128,7 +147,9 @@ func tagForParser(doc *Parser, start *Token, arguments *Parser) (INodeTag, *Erro
 	}
 
 	// Body wrapping
+	doc.loopDepth++
 	wrapper, endargs, err := doc.WrapUntilTag("empty", "endfor")
+	doc.loopDepth--
 	if err != nil {
 		return nil, err
 	}
@@ -6,27 +6,47 @@ import (
 
 type tagIfchangedNode struct {
 	watchedExpr []IEvaluator
-	lastValues  []*Value
-	lastContent []byte
 	thenWrapper *NodeWrapper
 	elseWrapper *NodeWrapper
 }
 
+// tagIfchangedState holds what an ifchanged-tag saw in the previous loop
+// iteration; it's kept in the execution context (see tagCycleState).
+type tagIfchangedState struct {
+	lastValues  []*Value
+	lastContent []byte
+}
+
 func (node *tagIfchangedNode) Execute(ctx *ExecutionContext, writer TemplateWriter) *Error {
+	var state *tagIfchangedState
+	if s, has := ctx.tagState(node); has {
+		state = s.(*tagIfchangedState)
+	} else {
+		state = &tagIfchangedState{}
+		ctx.setTagState(node, state)
+	}
+
 	if len(node.watchedExpr) == 0 {
 		// Check against own rendered body
 
-		buf := bytes.NewBuffer(make([]byte, 0, 1024)) // 1 KiB
-		err := node.thenWrapper.Execute(ctx, buf)
+		content, loopErr, err := executeBuffered(node.thenWrapper, ctx)
 		if err != nil {
 			return err
 		}
 
-		bufBytes := buf.Bytes()
-		if !bytes.Equal(node.lastContent, bufBytes) {
+		bufBytes := []byte(content)
+		if state.lastContent == nil || !bytes.Equal(state.lastContent, bufBytes) {
 			// Rendered content changed, output it
 			writer.Write(bufBytes)
-			node.lastContent = bufBytes
+			state.lastContent = bufBytes
+		} else if node.elseWrapper != nil && loopErr == nil {
+			// Render elseWrapper
+			if err := node.elseWrapper.Execute(ctx, writer); err != nil {
+				return err
+			}
+		}
+		if loopErr != nil {
+			return loopErr
 		}
 	} else {
 		nowValues := make([]*Value, 0, len(node.watchedExpr))
@@ -39,16 +59,16 @@ func (node *tagIfchangedNode) Execute(ctx *ExecutionContext, writer TemplateWrit
 		}
 
 		// Compare old to new values now
-		changed := len(node.lastValues) == 0
+		changed := len(state.lastValues) == 0
 
-		for idx, oldVal := range node.lastValues {
+		for idx, oldVal := range state.lastValues {
 			if !oldVal.EqualValueTo(nowValues[idx]) {
 				changed = true
 				break // we can stop here because ONE value changed
 			}
 		}
 
-		node.lastValues = nowValues
+		state.lastValues = nowValues
 
 		if changed {
 			// Render thenWrapper
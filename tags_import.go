@@ -13,9 +13,9 @@ type tagImportNode struct {
 func (node *tagImportNode) Execute(ctx *ExecutionContext, writer TemplateWriter) *Error {
 	for name, macro := range node.macros {
 		func(name string, macro *tagMacroNode) {
-			ctx.Private[name] = func(args ...*Value) (*Value, error) {
-				return macro.call(ctx, args...)
-			}
+			ctx.Private[name] = macroFunction(func(args []*Value, kwargs map[string]*Value) (*Value, error) {
+				return macro.call(ctx, args, kwargs)
+			})
 		}(name, macro)
 	}
 	return nil
@@ -41,7 +41,7 @@ func tagImportParser(doc *Parser, start *Token, arguments *Parser) (INodeTag, *E
 	// Compile the given template
 	tpl, err := doc.template.set.FromFile(importNode.filename)
 	if err != nil {
-		return nil, err.(*Error).updateFromTokenIfNeeded(doc.template, start)
+		return nil, asError(err).updateFromTokenIfNeeded(doc.template, start)
 	}
 
 	for arguments.Remaining() > 0 {
@@ -47,21 +47,21 @@ func (node *tagIncludeNode) Execute(ctx *ExecutionContext, writer TemplateWriter
 		includedTpl, err2 := ctx.template.set.FromFile(includedFilename)
 		if err2 != nil {
 			// if this is ReadFile error, and "if_exists" flag is enabled
-			if node.ifExists && err2.(*Error).Sender == "fromfile" {
+			if node.ifExists && asError(err2).Sender == "fromfile" {
 				return nil
 			}
-			return err2.(*Error)
+			return asError(err2)
 		}
 		err2 = includedTpl.ExecuteWriter(includeCtx, writer)
 		if err2 != nil {
-			return err2.(*Error)
+			return ctx.executionError(err2)
 		}
 		return nil
 	}
 	// Template is already parsed with static filename
 	err := node.tpl.ExecuteWriter(includeCtx, writer)
 	if err != nil {
-		return err.(*Error)
+		return ctx.executionError(err)
 	}
 	return nil
 }
@@ -72,6 +72,22 @@ func (node *tagIncludeEmptyNode) Execute(ctx *ExecutionContext, writer TemplateW
 	return nil
 }
 
+// parseIncludeIfExists parses the optional "if_exists" flag (or its
+// equivalent "ignore missing") which only suppresses errors of templates
+// which can't be loaded, but not of templates which fail to parse.
+func parseIncludeIfExists(arguments *Parser) (bool, *Error) {
+	if arguments.Match(TokenIdentifier, "if_exists") != nil {
+		return true, nil
+	}
+	if arguments.Match(TokenIdentifier, "ignore") != nil {
+		if arguments.Match(TokenIdentifier, "missing") == nil {
+			return false, arguments.Error("Expected 'missing' after 'ignore'.", nil)
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
 func tagIncludeParser(doc *Parser, start *Token, arguments *Parser) (INodeTag, *Error) {
 	includeNode := &tagIncludeNode{
 		withPairs: make(map[string]IEvaluator),
@@ -81,7 +97,10 @@ func tagIncludeParser(doc *Parser, start *Token, arguments *Parser) (INodeTag, *
 		// prepared, static template
 
 		// "if_exists" flag
-		ifExists := arguments.Match(TokenIdentifier, "if_exists") != nil
+		ifExists, flagErr := parseIncludeIfExists(arguments)
+		if flagErr != nil {
+			return nil, flagErr
+		}
 
 		// Get include-filename
 		includedFilename := doc.template.set.resolveFilename(doc.template, filenameToken.Val)
@@ -91,10 +110,10 @@ func tagIncludeParser(doc *Parser, start *Token, arguments *Parser) (INodeTag, *
 		includedTpl, err := doc.template.set.FromFile(includedFilename)
 		if err != nil {
 			// if this is ReadFile error, and "if_exists" token presents we should create and empty node
-			if err.(*Error).Sender == "fromfile" && ifExists {
+			if asError(err).Sender == "fromfile" && ifExists {
 				return &tagIncludeEmptyNode{}, nil
 			}
-			return nil, err.(*Error).updateFromTokenIfNeeded(doc.template, filenameToken)
+			return nil, asError(err).updateFromTokenIfNeeded(doc.template, filenameToken)
 		}
 		includeNode.tpl = includedTpl
 	} else {
@@ -105,10 +124,14 @@ func tagIncludeParser(doc *Parser, start *Token, arguments *Parser) (INodeTag, *
 		}
 		includeNode.filenameEvaluator = filenameEvaluator
 		includeNode.lazy = true
-		includeNode.ifExists = arguments.Match(TokenIdentifier, "if_exists") != nil // "if_exists" flag
+		includeNode.ifExists, err = parseIncludeIfExists(arguments) // "if_exists" flag
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	// After having parsed the filename we're gonna parse the with+only options
+	// (just "only" renders the included template without any context)
 	if arguments.Match(TokenIdentifier, "with") != nil {
 		for arguments.Remaining() > 0 {
 			// We have at least one key=expr pair (because of starting "with")
@@ -132,6 +155,9 @@ func tagIncludeParser(doc *Parser, start *Token, arguments *Parser) (INodeTag, *
 				break // stop parsing arguments because it's the last option
 			}
 		}
+	} else if arguments.Match(TokenIdentifier, "only") != nil {
+		// Render with an empty context
+		includeNode.only = true
 	}
 
 	if arguments.Remaining() > 0 {
@@ -0,0 +1,120 @@
+package pongo2
+
+import "io"
+
+type tagIncludeRawNode struct {
+	content           string
+	filenameEvaluator IEvaluator
+	lazy              bool
+	escaped           bool
+	ignoreMissing     bool
+}
+
+func (node *tagIncludeRawNode) Execute(ctx *ExecutionContext, writer TemplateWriter) *Error {
+	content := node.content
+
+	if node.lazy {
+		// Evaluate the filename
+		filename, err := node.filenameEvaluator.Evaluate(ctx)
+		if err != nil {
+			return err
+		}
+
+		if filename.String() == "" {
+			return ctx.Error("Filename for 'include_raw'-tag evaluated to an empty string.", nil)
+		}
+
+		var missing bool
+		content, missing, err = readRawFile(ctx.template, filename.String())
+		if err != nil {
+			if missing && node.ignoreMissing {
+				return nil
+			}
+			return err
+		}
+	}
+
+	if node.escaped {
+		escaped, _ := filterEscape(AsValue(content), nil, nil)
+		content = escaped.String()
+	}
+	writer.WriteString(content)
+	return nil
+}
+
+// readRawFile reads the file at path (relative to tpl) through the template
+// set's loaders without parsing it. missing is true if no loader could
+// provide the file.
+func readRawFile(tpl *Template, path string) (content string, missing bool, rawErr *Error) {
+	name, _, fd, err := tpl.set.resolveTemplate(tpl, path)
+	if err != nil {
+		return "", true, &Error{
+			Filename:  path,
+			Sender:    "tag:include_raw",
+			OrigError: err,
+		}
+	}
+	buf, err := io.ReadAll(fd)
+	if err != nil {
+		return "", false, &Error{
+			Filename:  name,
+			Sender:    "tag:include_raw",
+			OrigError: err,
+		}
+	}
+	return string(buf), false, nil
+}
+
+// tagIncludeRawParser parses
+//
+//	{% include_raw "path" [escaped] [ignore missing] %}
+//
+// which inserts the file's content as it is, without parsing it as a template.
+func tagIncludeRawParser(doc *Parser, start *Token, arguments *Parser) (INodeTag, *Error) {
+	includeRawNode := &tagIncludeRawNode{}
+
+	filenameToken := arguments.MatchType(TokenString)
+	if filenameToken == nil {
+		// No String, then the user wants to use lazy-evaluation
+		filenameEvaluator, err := arguments.ParseExpression()
+		if err != nil {
+			return nil, err
+		}
+		includeRawNode.filenameEvaluator = filenameEvaluator
+		includeRawNode.lazy = true
+	}
+
+	// Optional flags (in any order)
+	for arguments.Remaining() > 0 {
+		if arguments.Match(TokenIdentifier, "escaped") != nil {
+			includeRawNode.escaped = true
+			continue
+		}
+		ignoreMissing, err := parseIncludeIfExists(arguments)
+		if err != nil {
+			return nil, err
+		}
+		if !ignoreMissing {
+			return nil, arguments.Error("Malformed 'include_raw'-tag arguments.", nil)
+		}
+		includeRawNode.ignoreMissing = true
+	}
+
+	if filenameToken != nil {
+		// Static filename, so the file is read once while parsing
+		content, missing, err := readRawFile(doc.template, filenameToken.Val)
+		if err != nil {
+			if missing && includeRawNode.ignoreMissing {
+				return &tagIncludeEmptyNode{}, nil
+			}
+			return nil, err.updateFromTokenIfNeeded(doc.template, filenameToken)
+		}
+		includeRawNode.content = content
+	}
+
+	return includeRawNode, nil
+}
+
+func init() {
+	RegisterTag("include_raw", tagIncludeRawParser)
+}
@@ -26,6 +26,13 @@ func (node *tagLoremNode) Execute(ctx *ExecutionContext, writer TemplateWriter)
 		return ctx.Error(fmt.Sprintf("max count for lorem is %d", maxLoremCount), node.position)
 	}
 
+	// Random text is taken from the context's "rand" (a *rand.Rand) if
+	// given, so the output can be made deterministic by seeding it
+	intn := rand.Intn
+	if r, ok := ctx.Public["rand"].(*rand.Rand); ok {
+		intn = r.Intn
+	}
+
 	switch node.method {
 	case "b":
 		if node.random {
@@ -33,7 +40,7 @@ func (node *tagLoremNode) Execute(ctx *ExecutionContext, writer TemplateWriter)
 				if i > 0 {
 					writer.WriteString("\n")
 				}
-				par := tagLoremParagraphs[rand.Intn(len(tagLoremParagraphs))]
+				par := tagLoremParagraphs[intn(len(tagLoremParagraphs))]
 				writer.WriteString(par)
 			}
 		} else {
@@ -51,7 +58,7 @@ func (node *tagLoremNode) Execute(ctx *ExecutionContext, writer TemplateWriter)
 				if i > 0 {
 					writer.WriteString(" ")
 				}
-				word := tagLoremWords[rand.Intn(len(tagLoremWords))]
+				word := tagLoremWords[intn(len(tagLoremWords))]
 				writer.WriteString(word)
 			}
 		} else {
@@ -70,7 +77,7 @@ func (node *tagLoremNode) Execute(ctx *ExecutionContext, writer TemplateWriter)
 					writer.WriteString("\n")
 				}
 				writer.WriteString("<p>")
-				par := tagLoremParagraphs[rand.Intn(len(tagLoremParagraphs))]
+				par := tagLoremParagraphs[intn(len(tagLoremParagraphs))]
 				writer.WriteString(par)
 				writer.WriteString("</p>")
 			}
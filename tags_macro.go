@@ -7,6 +7,10 @@ import (
 
 const maxMacroDepth = 1000
 
+// macroFunction is how a macro is made available in the context. Contrary to
+// an ordinary function it can be called with keyword arguments.
+type macroFunction func(args []*Value, kwargs map[string]*Value) (*Value, error)
+
 type tagMacroNode struct {
 	position  *Token
 	name      string
@@ -18,7 +22,7 @@ type tagMacroNode struct {
 }
 
 func (node *tagMacroNode) Execute(ctx *ExecutionContext, writer TemplateWriter) *Error {
-	ctx.Private[node.name] = func(args ...*Value) (*Value, error) {
+	ctx.Private[node.name] = macroFunction(func(args []*Value, kwargs map[string]*Value) (*Value, error) {
 		ctx.macroDepth++
 		defer func() {
 			ctx.macroDepth--
@@ -28,31 +32,13 @@ func (node *tagMacroNode) Execute(ctx *ExecutionContext, writer TemplateWriter)
 			return nil, ctx.Error(fmt.Sprintf("maximum recursive macro call depth reached (max is %v)", maxMacroDepth), node.position)
 		}
 
-		return node.call(ctx, args...)
-	}
+		return node.call(ctx, args, kwargs)
+	})
 
 	return nil
 }
 
-func (node *tagMacroNode) call(ctx *ExecutionContext, args ...*Value) (*Value, error) {
-	argsCtx := make(Context)
-
-	for k, v := range node.args {
-		if v == nil {
-			// User did not provided a default value
-			argsCtx[k] = nil
-		} else {
-			// Evaluate the default value
-			valueExpr, err := v.Evaluate(ctx)
-			if err != nil {
-				ctx.Logf(err.Error())
-				return AsSafeValue(""), err
-			}
-
-			argsCtx[k] = valueExpr
-		}
-	}
-
+func (node *tagMacroNode) call(ctx *ExecutionContext, args []*Value, kwargs map[string]*Value) (*Value, error) {
 	if len(args) > len(node.argsOrder) {
 		// Too many arguments, we're ignoring them and just logging into debug mode.
 		err := ctx.Error(fmt.Sprintf("Macro '%s' called with too many arguments (%d instead of %d).",
@@ -61,16 +47,57 @@ func (node *tagMacroNode) call(ctx *ExecutionContext, args ...*Value) (*Value, e
 		return AsSafeValue(""), err
 	}
 
+	for name := range kwargs {
+		idx := -1
+		for i, argName := range node.argsOrder {
+			if argName == name {
+				idx = i
+				break
+			}
+		}
+		if idx < 0 {
+			return AsSafeValue(""), ctx.Error(fmt.Sprintf("Macro '%s' has no argument named '%s'.",
+				node.name, name), nil).updateFromTokenIfNeeded(ctx.template, node.position)
+		}
+		if idx < len(args) {
+			return AsSafeValue(""), ctx.Error(fmt.Sprintf("Macro '%s' got multiple values for argument '%s'.",
+				node.name, name), nil).updateFromTokenIfNeeded(ctx.template, node.position)
+		}
+	}
+
+	argsCtx := make(Context)
+
+	for idx, name := range node.argsOrder {
+		if idx < len(args) {
+			argsCtx[name] = args[idx].Interface()
+			continue
+		}
+		if kwarg, has := kwargs[name]; has {
+			argsCtx[name] = kwarg.Interface()
+			continue
+		}
+
+		defaultExpr := node.args[name]
+		if defaultExpr == nil {
+			return AsSafeValue(""), ctx.Error(fmt.Sprintf("Macro '%s' is missing the required argument '%s'.",
+				node.name, name), nil).updateFromTokenIfNeeded(ctx.template, node.position)
+		}
+
+		// Evaluate the default value
+		valueExpr, err := defaultExpr.Evaluate(ctx)
+		if err != nil {
+			ctx.Logf(err.Error())
+			return AsSafeValue(""), err
+		}
+		argsCtx[name] = valueExpr
+	}
+
 	// Make a context for the macro execution
 	macroCtx := NewChildExecutionContext(ctx)
 
 	// Register all arguments in the private context
 	macroCtx.Private.Update(argsCtx)
 
-	for idx, argValue := range args {
-		macroCtx.Private[node.argsOrder[idx]] = argValue.Interface()
-	}
-
 	var b bytes.Buffer
 	err := node.wrapper.Execute(macroCtx, &b)
 	if err != nil {
@@ -105,7 +132,9 @@ func tagMacroParser(doc *Parser, start *Token, arguments *Parser) (INodeTag, *Er
 
 		if arguments.Match(TokenSymbol, "=") != nil {
 			// Default expression follows
+			arguments.argumentListDepth++
 			argDefaultExpr, err := arguments.ParseExpression()
+			arguments.argumentListDepth--
 			if err != nil {
 				return nil, err
 			}
@@ -132,7 +161,11 @@ func tagMacroParser(doc *Parser, start *Token, arguments *Parser) (INodeTag, *Er
 	}
 
 	// Body wrapping
+	// A macro's body is not part of a surrounding loop
+	loopDepth := doc.loopDepth
+	doc.loopDepth = 0
 	wrapper, endargs, err := doc.WrapUntilTag("endmacro")
+	doc.loopDepth = loopDepth
 	if err != nil {
 		return nil, err
 	}
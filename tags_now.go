@@ -1,13 +1,28 @@
 package pongo2
 
 import (
+	"fmt"
 	"time"
 )
 
 type tagNowNode struct {
 	position *Token
 	format   string
+	timezone IEvaluator
 	fake     bool
+	asName   string
+}
+
+// now returns the current time. It can be overridden by a time.Time or a
+// func() time.Time given as "__now__" in the public context.
+func (node *tagNowNode) now(ctx *ExecutionContext) time.Time {
+	switch now := ctx.Public["__now__"].(type) {
+	case time.Time:
+		return now
+	case func() time.Time:
+		return now()
+	}
+	return time.Now()
 }
 
 func (node *tagNowNode) Execute(ctx *ExecutionContext, writer TemplateWriter) *Error {
@@ -15,14 +30,41 @@ func (node *tagNowNode) Execute(ctx *ExecutionContext, writer TemplateWriter) *E
 	if node.fake {
 		t = time.Date(2014, time.February, 05, 18, 31, 45, 00, time.UTC)
 	} else {
-		t = time.Now()
+		t = node.now(ctx)
 	}
 
-	writer.WriteString(t.Format(node.format))
+	if node.timezone != nil {
+		tz, err := node.timezone.Evaluate(ctx)
+		if err != nil {
+			return err
+		}
+		switch loc := tz.Interface().(type) {
+		case *time.Location:
+			t = t.In(loc)
+		default:
+			l, lerr := time.LoadLocation(tz.String())
+			if lerr != nil {
+				return ctx.Error(fmt.Sprintf("Invalid timezone '%s': %v", tz.String(), lerr), node.position)
+			}
+			t = t.In(l)
+		}
+	}
+
+	formatted := filterDateFormat(t, node.format)
+
+	if node.asName != "" {
+		ctx.Private[node.asName] = formatted
+		return nil
+	}
+	writer.WriteString(formatted)
 
 	return nil
 }
 
+// {% now "format" ["timezone"] [fake] [as varname] %}
+//
+// The format is either a Go reference layout or consists of Django's format
+// characters (like the date-filter).
 func tagNowParser(doc *Parser, start *Token, arguments *Parser) (INodeTag, *Error) {
 	nowNode := &tagNowNode{
 		position: start,
@@ -34,10 +76,26 @@ func tagNowParser(doc *Parser, start *Token, arguments *Parser) (INodeTag, *Erro
 	}
 	nowNode.format = formatToken.Val
 
+	if arguments.Remaining() > 0 && arguments.Peek(TokenIdentifier, "fake") == nil && arguments.Peek(TokenKeyword, "as") == nil {
+		timezone, err := arguments.ParseExpression()
+		if err != nil {
+			return nil, err
+		}
+		nowNode.timezone = timezone
+	}
+
 	if arguments.MatchOne(TokenIdentifier, "fake") != nil {
 		nowNode.fake = true
 	}
 
+	if arguments.Match(TokenKeyword, "as") != nil {
+		nameToken := arguments.MatchType(TokenIdentifier)
+		if nameToken == nil {
+			return nil, arguments.Error("Name (identifier) expected after 'as'.", nil)
+		}
+		nowNode.asName = nameToken.Val
+	}
+
 	if arguments.Remaining() > 0 {
 		return nil, arguments.Error("Malformed now-tag arguments.", nil)
 	}
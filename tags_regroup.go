@@ -0,0 +1,103 @@
+package pongo2
+
+import (
+	"strings"
+)
+
+// tagRegroupNode groups a list by an attribute of its items, like Django's
+// regroup-tag:
+//
+//	{% regroup comments by Author.Name as grouped %}
+//	{% for group in grouped %}{{ group.grouper }}: {{ group.list|length }}{% endfor %}
+//
+// Just like in Django only consecutive items having the same attribute value
+// form a group, so the input should be sorted by the attribute beforehand
+// (the group_by-filter collects all items of a grouper instead).
+type tagRegroupNode struct {
+	position   *Token
+	expression IEvaluator
+	attr       string
+	name       string
+}
+
+func (node *tagRegroupNode) Execute(ctx *ExecutionContext, writer TemplateWriter) *Error {
+	obj, err := node.expression.Evaluate(ctx)
+	if err != nil {
+		return err
+	}
+
+	groups := make([]map[string]any, 0)
+	if obj.CanSlice() {
+		var last *Value
+		for i := 0; i < obj.Len(); i++ {
+			item := obj.Index(i)
+			grouper := item.getAttribute(node.attr)
+
+			if len(groups) == 0 || !((last.IsNil() && grouper.IsNil()) || last.EqualValueTo(grouper)) {
+				groups = append(groups, map[string]any{
+					"grouper": grouper.Interface(),
+					"list":    []any{},
+				})
+				last = grouper
+			}
+			group := groups[len(groups)-1]
+			group["list"] = append(group["list"].([]any), item.Interface())
+		}
+	}
+
+	ctx.Private[node.name] = AsValue(groups)
+	return nil
+}
+
+func tagRegroupParser(doc *Parser, start *Token, arguments *Parser) (INodeTag, *Error) {
+	regroupNode := &tagRegroupNode{
+		position: start,
+	}
+
+	expr, err := arguments.ParseExpression()
+	if err != nil {
+		return nil, err
+	}
+	regroupNode.expression = expr
+
+	if arguments.Match(TokenIdentifier, "by") == nil {
+		return nil, arguments.Error("Expected 'by'.", nil)
+	}
+
+	// Attribute path (like Author.Name)
+	var attr []string
+	for {
+		attrToken := arguments.MatchType(TokenIdentifier)
+		if attrToken == nil {
+			attrToken = arguments.MatchType(TokenNumber)
+		}
+		if attrToken == nil {
+			return nil, arguments.Error("Attribute name expected after 'by'.", nil)
+		}
+		attr = append(attr, attrToken.Val)
+		if arguments.Match(TokenSymbol, ".") == nil {
+			break
+		}
+	}
+	regroupNode.attr = strings.Join(attr, ".")
+
+	if arguments.Match(TokenKeyword, "as") == nil {
+		return nil, arguments.Error("Expected 'as'.", nil)
+	}
+
+	nameToken := arguments.MatchType(TokenIdentifier)
+	if nameToken == nil {
+		return nil, arguments.Error("Name (identifier) expected after 'as'.", nil)
+	}
+	regroupNode.name = nameToken.Val
+
+	if arguments.Remaining() > 0 {
+		return nil, arguments.Error("Malformed regroup-tag arguments.", nil)
+	}
+
+	return regroupNode, nil
+}
+
+func init() {
+	RegisterTag("regroup", tagRegroupParser)
+}
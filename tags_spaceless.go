@@ -1,9 +1,6 @@
 package pongo2
 
-import (
-	"bytes"
-	"regexp"
-)
+import "regexp"
 
 type tagSpacelessNode struct {
 	wrapper *NodeWrapper
@@ -12,14 +9,11 @@ type tagSpacelessNode struct {
 var tagSpacelessRegexp = regexp.MustCompile(`(?U:(<.*>))([\t\n\v\f\r ]+)(?U:(<.*>))`)
 
 func (node *tagSpacelessNode) Execute(ctx *ExecutionContext, writer TemplateWriter) *Error {
-	b := bytes.NewBuffer(make([]byte, 0, 1024)) // 1 KiB
-
-	err := node.wrapper.Execute(ctx, b)
+	s, loopErr, err := executeBuffered(node.wrapper, ctx)
 	if err != nil {
 		return err
 	}
 
-	s := b.String()
 	// Repeat this recursively
 	changed := true
 	for changed {
@@ -30,7 +24,7 @@ func (node *tagSpacelessNode) Execute(ctx *ExecutionContext, writer TemplateWrit
 
 	writer.WriteString(s)
 
-	return nil
+	return loopErr
 }
 
 func tagSpacelessParser(doc *Parser, start *Token, arguments *Parser) (INodeTag, *Error) {
@@ -0,0 +1,118 @@
+package pongo2
+
+import (
+	"strings"
+)
+
+type tagSwitchCase struct {
+	values  []IEvaluator
+	wrapper *NodeWrapper
+}
+
+type tagSwitchNode struct {
+	subject        IEvaluator
+	cases          []*tagSwitchCase
+	defaultWrapper *NodeWrapper
+}
+
+func (node *tagSwitchNode) Execute(ctx *ExecutionContext, writer TemplateWriter) *Error {
+	// The subject is evaluated only once
+	subject, err := node.subject.Evaluate(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, c := range node.cases {
+		for _, value := range c.values {
+			v, err := value.Evaluate(ctx)
+			if err != nil {
+				return err
+			}
+			if subject.EqualValueTo(v) {
+				return c.wrapper.Execute(ctx, writer)
+			}
+		}
+	}
+
+	if node.defaultWrapper != nil {
+		return node.defaultWrapper.Execute(ctx, writer)
+	}
+	return nil
+}
+
+func tagSwitchParser(doc *Parser, start *Token, arguments *Parser) (INodeTag, *Error) {
+	switchNode := &tagSwitchNode{}
+
+	subject, err := arguments.ParseExpression()
+	if err != nil {
+		return nil, err
+	}
+	switchNode.subject = subject
+
+	if arguments.Remaining() > 0 {
+		return nil, arguments.Error("Switch-subject is malformed.", nil)
+	}
+
+	// Only whitespace is allowed between the switch and the first case
+	wrapper, tagArgs, err := doc.WrapUntilTag("case", "default", "endswitch")
+	if err != nil {
+		return nil, err
+	}
+	for _, n := range wrapper.nodes {
+		html, isHTML := n.(*nodeHTML)
+		if !isHTML || strings.TrimSpace(html.token.Val) != "" {
+			return nil, doc.Error("Only whitespace is allowed between 'switch' and the first 'case'.", start)
+		}
+	}
+
+	for wrapper.Endtag != "endswitch" {
+		endtag := wrapper.Endtag
+		caseArgs := tagArgs
+
+		wrapper, tagArgs, err = doc.WrapUntilTag("case", "default", "endswitch")
+		if err != nil {
+			return nil, err
+		}
+
+		if endtag == "default" {
+			if caseArgs.Count() > 0 {
+				return nil, caseArgs.Error("Arguments not allowed here.", nil)
+			}
+			if wrapper.Endtag != "endswitch" {
+				return nil, doc.Error("No 'case' or 'default' is allowed after 'default'.", nil)
+			}
+			switchNode.defaultWrapper = wrapper
+			break
+		}
+
+		// case can take multiple comma-separated values
+		switchCase := &tagSwitchCase{wrapper: wrapper}
+		caseArgs.argumentListDepth++
+		for {
+			value, err := caseArgs.ParseExpression()
+			if err != nil {
+				return nil, err
+			}
+			switchCase.values = append(switchCase.values, value)
+
+			if caseArgs.Match(TokenSymbol, ",") == nil {
+				break
+			}
+		}
+		caseArgs.argumentListDepth--
+		if caseArgs.Remaining() > 0 {
+			return nil, caseArgs.Error("Case-value is malformed.", nil)
+		}
+		switchNode.cases = append(switchNode.cases, switchCase)
+	}
+
+	if tagArgs.Count() > 0 {
+		return nil, tagArgs.Error("Arguments not allowed here.", nil)
+	}
+
+	return switchNode, nil
+}
+
+func init() {
+	RegisterTag("switch", tagSwitchParser)
+}
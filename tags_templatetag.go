@@ -1,18 +1,26 @@
 package pongo2
 
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
 type tagTemplateTagNode struct {
 	content string
 }
 
-var templateTagMapping = map[string]string{
-	"openblock":     "{%",
-	"closeblock":    "%}",
-	"openvariable":  "{{",
-	"closevariable": "}}",
-	"openbrace":     "{",
-	"closebrace":    "}",
-	"opencomment":   "{#",
-	"closecomment":  "#}",
+// templateTagMapping returns the output of each templatetag-argument; the
+// delimiters are the ones configured for the template's set.
+var templateTagMapping = map[string]func(d *delimiters) string{
+	"openblock":     func(d *delimiters) string { return d.tagStart },
+	"closeblock":    func(d *delimiters) string { return d.tagEnd },
+	"openvariable":  func(d *delimiters) string { return d.varStart },
+	"closevariable": func(d *delimiters) string { return d.varEnd },
+	"openbrace":     func(d *delimiters) string { return "{" },
+	"closebrace":    func(d *delimiters) string { return "}" },
+	"opencomment":   func(d *delimiters) string { return d.commentStart },
+	"closecomment":  func(d *delimiters) string { return d.commentEnd },
 }
 
 func (node *tagTemplateTagNode) Execute(ctx *ExecutionContext, writer TemplateWriter) *Error {
@@ -26,9 +34,14 @@ func tagTemplateTagParser(doc *Parser, start *Token, arguments *Parser) (INodeTa
 	if argToken := arguments.MatchType(TokenIdentifier); argToken != nil {
 		output, found := templateTagMapping[argToken.Val]
 		if !found {
-			return nil, arguments.Error("Argument not found", argToken)
+			names := make([]string, 0, len(templateTagMapping))
+			for name := range templateTagMapping {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			return nil, arguments.Error(fmt.Sprintf("Unknown templatetag-argument '%s' (must be one of %s).", argToken.Val, strings.Join(names, ", ")), argToken)
 		}
-		ttNode.content = output
+		ttNode.content = output(doc.template.set.delimiters)
 	} else {
 		return nil, arguments.Error("Identifier expected.", nil)
 	}
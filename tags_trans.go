@@ -0,0 +1,317 @@
+package pongo2
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Translator translates the messages of the trans- and blocktrans-tag. msgid
+// is the message in the template's source language; placeholders for
+// variables are written as "{{ name }}" and vars holds their values. The
+// placeholders of the returned translation are replaced by the tag.
+type Translator interface {
+	Translate(ctx *ExecutionContext, msgid string, vars map[string]any) string
+}
+
+// PluralTranslator can optionally be implemented by a Translator to
+// choose the plural form of a blocktrans-tag with a count itself (e. g.
+// for languages with more than one plural form). Otherwise the singular
+// form is translated if count equals 1 and the plural form else.
+type PluralTranslator interface {
+	TranslatePlural(ctx *ExecutionContext, singular, plural string, count int, vars map[string]any) string
+}
+
+var tagTransPlaceholderRegexp = regexp.MustCompile(`\{\{ *([a-zA-Z0-9_]+) *\}\}`)
+
+// translate translates msgid using the template set's translator (if any)
+func translate(ctx *ExecutionContext, msgid string, vars map[string]any) string {
+	translator := ctx.template.set.Translator
+	if translator == nil {
+		return msgid
+	}
+	return translator.Translate(ctx, msgid, vars)
+}
+
+type tagTransNode struct {
+	position  *Token
+	msgid     IEvaluator
+	isLiteral bool
+	asName    string
+}
+
+func (node *tagTransNode) Execute(ctx *ExecutionContext, writer TemplateWriter) *Error {
+	msgid, err := node.msgid.Evaluate(ctx)
+	if err != nil {
+		return err
+	}
+
+	translated := AsValue(translate(ctx, msgid.String(), map[string]any{}))
+	if node.asName != "" {
+		ctx.Private[node.asName] = translated
+		return nil
+	}
+
+	// Messages given as string literals are considered safe (like in Django)
+	if ctx.Autoescape && !node.isLiteral && !msgid.safe {
+		translated, err = filterEscape(translated, nil, ctx.Public)
+		if err != nil {
+			return err
+		}
+	}
+	writer.WriteString(translated.String())
+
+	return nil
+}
+
+// {% trans "message" [as varname] %}
+func tagTransParser(doc *Parser, start *Token, arguments *Parser) (INodeTag, *Error) {
+	transNode := &tagTransNode{
+		position: start,
+	}
+
+	msgid, err := arguments.ParseExpression()
+	if err != nil {
+		return nil, err
+	}
+	transNode.msgid = msgid
+	if fv, ok := msgid.(*nodeFilteredVariable); ok && len(fv.filterChain) == 0 {
+		_, transNode.isLiteral = fv.resolver.(*stringResolver)
+	}
+
+	if arguments.Match(TokenKeyword, "as") != nil {
+		nameToken := arguments.MatchType(TokenIdentifier)
+		if nameToken == nil {
+			return nil, arguments.Error("Name (identifier) expected after 'as'.", nil)
+		}
+		transNode.asName = nameToken.Val
+	}
+
+	if arguments.Remaining() > 0 {
+		return nil, arguments.Error("Malformed trans-tag arguments.", nil)
+	}
+
+	return transNode, nil
+}
+
+type tagBlocktransNode struct {
+	position *Token
+
+	singular     string
+	plural       string
+	placeholders []string // all variables used within the messages
+
+	withPairs map[string]IEvaluator
+	countName string
+	count     IEvaluator
+}
+
+func (node *tagBlocktransNode) Execute(ctx *ExecutionContext, writer TemplateWriter) *Error {
+	vars := make(map[string]any)
+	values := make(map[string]*Value)
+
+	for name, expr := range node.withPairs {
+		val, err := expr.Evaluate(ctx)
+		if err != nil {
+			return err
+		}
+		values[name] = val
+	}
+
+	count := 0
+	if node.count != nil {
+		val, err := node.count.Evaluate(ctx)
+		if err != nil {
+			return err
+		}
+		values[node.countName] = val
+		count = val.Integer()
+	}
+
+	// All other placeholders are looked up in the context
+	for _, name := range node.placeholders {
+		if _, has := values[name]; has {
+			continue
+		}
+		resolver := &variableResolver{
+			locationToken: node.position,
+			parts:         []*variablePart{{typ: varTypeIdent, s: name}},
+		}
+		val, err := resolver.Evaluate(ctx)
+		if err != nil {
+			return err
+		}
+		values[name] = val
+	}
+
+	for name, val := range values {
+		vars[name] = val.Interface()
+	}
+
+	var translated string
+	switch {
+	case node.count == nil:
+		translated = translate(ctx, node.singular, vars)
+	case ctx.template.set.Translator == nil:
+		if count == 1 {
+			translated = node.singular
+		} else {
+			translated = node.plural
+		}
+	default:
+		if translator, ok := ctx.template.set.Translator.(PluralTranslator); ok {
+			translated = translator.TranslatePlural(ctx, node.singular, node.plural, count, vars)
+		} else if count == 1 {
+			translated = translate(ctx, node.singular, vars)
+		} else {
+			translated = translate(ctx, node.plural, vars)
+		}
+	}
+
+	var escapeErr *Error
+	translated = tagTransPlaceholderRegexp.ReplaceAllStringFunc(translated, func(placeholder string) string {
+		name := tagTransPlaceholderRegexp.FindStringSubmatch(placeholder)[1]
+		val, has := values[name]
+		if !has {
+			return placeholder
+		}
+		if ctx.Autoescape && !val.safe && val.IsString() {
+			escaped, err := filterEscape(val, nil, ctx.Public)
+			if err != nil {
+				escapeErr = err
+				return ""
+			}
+			val = escaped
+		}
+		return val.String()
+	})
+	if escapeErr != nil {
+		return escapeErr
+	}
+
+	writer.WriteString(translated)
+
+	return nil
+}
+
+// parseMessage reads the message of a blocktrans-tag up to one of
+// the given end tags. Only plain text and simple variables are allowed.
+func (node *tagBlocktransNode) parseMessage(doc *Parser, endtags ...string) (string, string, *Error) {
+	var msg bytes.Buffer
+
+	for doc.Remaining() > 0 {
+		t := doc.Current()
+		switch {
+		case t.Typ == TokenHTML:
+			msg.WriteString(t.Val)
+			doc.Consume()
+		case t.Typ == TokenSymbol && t.Val == "{{":
+			doc.Consume()
+			nameToken := doc.MatchType(TokenIdentifier)
+			if nameToken == nil || doc.Match(TokenSymbol, "}}") == nil {
+				return "", "", doc.Error("Only simple variables (without attributes or filters) are allowed within blocktrans.", t)
+			}
+			fmt.Fprintf(&msg, "{{ %s }}", nameToken.Val)
+
+			known := false
+			for _, name := range node.placeholders {
+				if name == nameToken.Val {
+					known = true
+					break
+				}
+			}
+			if !known {
+				node.placeholders = append(node.placeholders, nameToken.Val)
+			}
+		case t.Typ == TokenSymbol && t.Val == "{%":
+			tagIdent := doc.PeekTypeN(1, TokenIdentifier)
+			if tagIdent == nil {
+				return "", "", doc.Error("Tag name must be an identifier.", t)
+			}
+			for _, endtag := range endtags {
+				if tagIdent.Val == endtag {
+					doc.ConsumeN(2) // '{%' tagname
+					if doc.Match(TokenSymbol, "%}") == nil {
+						return "", "", doc.Error(fmt.Sprintf("Tag '%s' does not take any argument.", endtag), tagIdent)
+					}
+					return msg.String(), endtag, nil
+				}
+			}
+			return "", "", doc.Error(fmt.Sprintf("Tag '%s' is not allowed within blocktrans.", tagIdent.Val), tagIdent)
+		default:
+			return "", "", doc.Error("Unexpected token within blocktrans.", t)
+		}
+	}
+
+	return "", "", doc.Error(fmt.Sprintf("Unexpected EOF, expected tag %s.", strings.Join(endtags, " or ")),
+		doc.lastToken)
+}
+
+// {% blocktrans [with name=expr ...] [count name=expr] %}...[{% plural %}...]{% endblocktrans %}
+func tagBlocktransParser(doc *Parser, start *Token, arguments *Parser) (INodeTag, *Error) {
+	blocktransNode := &tagBlocktransNode{
+		position:  start,
+		withPairs: make(map[string]IEvaluator),
+	}
+
+	for arguments.Remaining() > 0 {
+		switch {
+		case arguments.Match(TokenIdentifier, "with") != nil:
+			for arguments.PeekType(TokenIdentifier) != nil && arguments.PeekN(1, TokenSymbol, "=") != nil {
+				keyToken := arguments.MatchType(TokenIdentifier)
+				arguments.Consume() // '='
+				valueExpr, err := arguments.ParseExpression()
+				if err != nil {
+					return nil, err
+				}
+				blocktransNode.withPairs[keyToken.Val] = valueExpr
+			}
+			if len(blocktransNode.withPairs) == 0 {
+				return nil, arguments.Error("Expected at least one 'name=value' after 'with'.", nil)
+			}
+		case arguments.Match(TokenIdentifier, "count") != nil:
+			if blocktransNode.count != nil {
+				return nil, arguments.Error("Only one 'count' is allowed.", nil)
+			}
+			keyToken := arguments.MatchType(TokenIdentifier)
+			if keyToken == nil || arguments.Match(TokenSymbol, "=") == nil {
+				return nil, arguments.Error("Expected 'name=value' after 'count'.", nil)
+			}
+			valueExpr, err := arguments.ParseExpression()
+			if err != nil {
+				return nil, err
+			}
+			blocktransNode.countName = keyToken.Val
+			blocktransNode.count = valueExpr
+		default:
+			return nil, arguments.Error("Malformed blocktrans-tag arguments.", nil)
+		}
+	}
+
+	singular, endtag, err := blocktransNode.parseMessage(doc, "plural", "endblocktrans")
+	if err != nil {
+		return nil, err
+	}
+	blocktransNode.singular = singular
+
+	if endtag == "plural" {
+		if blocktransNode.count == nil {
+			return nil, doc.Error("'plural' requires a 'count' in the blocktrans-tag.", start)
+		}
+		plural, _, err := blocktransNode.parseMessage(doc, "endblocktrans")
+		if err != nil {
+			return nil, err
+		}
+		blocktransNode.plural = plural
+	} else if blocktransNode.count != nil {
+		return nil, doc.Error("A blocktrans-tag with a 'count' requires a 'plural'.", start)
+	}
+
+	return blocktransNode, nil
+}
+
+func init() {
+	RegisterTag("trans", tagTransParser)
+	RegisterTag("blocktrans", tagBlocktransParser)
+}
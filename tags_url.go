@@ -0,0 +1,124 @@
+package pongo2
+
+import (
+	"fmt"
+)
+
+type tagURLNode struct {
+	position *Token
+	name     IEvaluator
+	args     []IEvaluator
+	kwargs   map[string]IEvaluator
+	asName   string
+}
+
+func (node *tagURLNode) Execute(ctx *ExecutionContext, writer TemplateWriter) *Error {
+	resolver := ctx.template.set.URLResolver
+	if resolver == nil {
+		return ctx.Error("No URL resolver registered on the template set (TemplateSet.URLResolver).", node.position)
+	}
+
+	name, err := node.name.Evaluate(ctx)
+	if err != nil {
+		return err
+	}
+
+	args := make([]*Value, 0, len(node.args))
+	for _, argExpr := range node.args {
+		arg, err := argExpr.Evaluate(ctx)
+		if err != nil {
+			return err
+		}
+		args = append(args, arg)
+	}
+
+	kwargs := make(map[string]*Value, len(node.kwargs))
+	for key, argExpr := range node.kwargs {
+		arg, err := argExpr.Evaluate(ctx)
+		if err != nil {
+			return err
+		}
+		kwargs[key] = arg
+	}
+
+	url, rerr := resolver(name.String(), args, kwargs)
+	if rerr != nil {
+		return ctx.OrigError(fmt.Errorf("resolving URL '%s' failed: %w", name.String(), rerr), node.position)
+	}
+
+	if node.asName != "" {
+		ctx.Private[node.asName] = url
+		return nil
+	}
+
+	value := AsValue(url)
+	if ctx.Autoescape {
+		value, err = filterEscape(value, nil, ctx.Public)
+		if err != nil {
+			return err
+		}
+	}
+	writer.WriteString(value.String())
+
+	return nil
+}
+
+// {% url "route_name" arg1 arg2 key=value [as varname] %}
+func tagURLParser(doc *Parser, start *Token, arguments *Parser) (INodeTag, *Error) {
+	urlNode := &tagURLNode{
+		position: start,
+		kwargs:   make(map[string]IEvaluator),
+	}
+
+	name, err := arguments.ParseExpression()
+	if err != nil {
+		return nil, err
+	}
+	urlNode.name = name
+
+	for arguments.Remaining() > 0 {
+		if arguments.Match(TokenKeyword, "as") != nil {
+			nameToken := arguments.MatchType(TokenIdentifier)
+			if nameToken == nil {
+				return nil, arguments.Error("Name (identifier) expected after 'as'.", nil)
+			}
+			urlNode.asName = nameToken.Val
+
+			// Now we're finished
+			break
+		}
+
+		if keyToken := arguments.PeekType(TokenIdentifier); keyToken != nil && arguments.PeekN(1, TokenSymbol, "=") != nil {
+			// key=value
+			arguments.ConsumeN(2)
+			if _, has := urlNode.kwargs[keyToken.Val]; has {
+				return nil, arguments.Error(fmt.Sprintf("Keyword argument '%s' given twice.", keyToken.Val), keyToken)
+			}
+			argExpr, err := arguments.ParseExpression()
+			if err != nil {
+				return nil, err
+			}
+			urlNode.kwargs[keyToken.Val] = argExpr
+			continue
+		}
+
+		if len(urlNode.kwargs) > 0 {
+			return nil, arguments.Error("Positional arguments must not follow keyword arguments.", nil)
+		}
+		argExpr, err := arguments.ParseExpression()
+		if err != nil {
+			return nil, err
+		}
+		urlNode.args = append(urlNode.args, argExpr)
+	}
+
+	if arguments.Remaining() > 0 {
+		return nil, arguments.Error("Malformed url-tag arguments.", nil)
+	}
+
+	return urlNode, nil
+}
+
+func init() {
+	RegisterTag("url", tagURLParser)
+}
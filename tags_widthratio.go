@@ -28,7 +28,12 @@ func (node *tagWidthratioNode) Execute(ctx *ExecutionContext, writer TemplateWri
 		return err
 	}
 
-	value := int(math.Ceil(current.Float()/max.Float()*width.Float() + 0.5))
+	// A zero max (like an empty progress bar) yields 0 instead of an error;
+	// the ratio is rounded half-up
+	value := 0
+	if max.Float() != 0 {
+		value = int(math.Floor(current.Float()/max.Float()*width.Float() + 0.5))
+	}
 
 	if node.ctxName == "" {
 		writer.WriteString(fmt.Sprintf("%d", value))
@@ -1,7 +1,12 @@
 package pongo2
 
+type tagWithPair struct {
+	key   string
+	value IEvaluator
+}
+
 type tagWithNode struct {
-	withPairs map[string]IEvaluator
+	withPairs []tagWithPair // in order of appearance
 	wrapper   *NodeWrapper
 }
 
@@ -9,22 +14,21 @@ func (node *tagWithNode) Execute(ctx *ExecutionContext, writer TemplateWriter) *
 	// new context for block
 	withctx := NewChildExecutionContext(ctx)
 
-	// Put all custom with-pairs into the context
-	for key, value := range node.withPairs {
-		val, err := value.Evaluate(ctx)
+	// Put all custom with-pairs into the context; they're evaluated from
+	// left to right, so a pair can refer to the ones before it
+	for _, pair := range node.withPairs {
+		val, err := pair.value.Evaluate(withctx)
 		if err != nil {
 			return err
 		}
-		withctx.Private[key] = val
+		withctx.Private[pair.key] = val
 	}
 
 	return node.wrapper.Execute(withctx, writer)
 }
 
 func tagWithParser(doc *Parser, start *Token, arguments *Parser) (INodeTag, *Error) {
-	withNode := &tagWithNode{
-		withPairs: make(map[string]IEvaluator),
-	}
+	withNode := &tagWithNode{}
 
 	if arguments.Count() == 0 {
 		return nil, arguments.Error("Tag 'with' requires at least one argument.", nil)
@@ -63,7 +67,7 @@ func tagWithParser(doc *Parser, start *Token, arguments *Parser) (INodeTag, *Err
 			if keyToken == nil {
 				return nil, arguments.Error("Expected an identifier", nil)
 			}
-			withNode.withPairs[keyToken.Val] = valueExpr
+			withNode.withPairs = append(withNode.withPairs, tagWithPair{key: keyToken.Val, value: valueExpr})
 		} else {
 			keyToken := arguments.MatchType(TokenIdentifier)
 			if keyToken == nil {
@@ -76,7 +80,7 @@ func tagWithParser(doc *Parser, start *Token, arguments *Parser) (INodeTag, *Err
 			if err != nil {
 				return nil, err
 			}
-			withNode.withPairs[keyToken.Val] = valueExpr
+			withNode.withPairs = append(withNode.withPairs, tagWithPair{key: keyToken.Val, value: valueExpr})
 		}
 	}
 
@@ -2,8 +2,10 @@ package pongo2
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
+	"sort"
 	"strings"
 )
 
@@ -13,15 +15,33 @@ type TemplateWriter interface {
 }
 
 type templateWriter struct {
-	w io.Writer
+	w   io.Writer
+	err error // first error returned by w; no writes are attempted afterwards
 }
 
 func (tw *templateWriter) WriteString(s string) (int, error) {
-	return tw.w.Write([]byte(s))
+	return tw.Write([]byte(s))
 }
 
 func (tw *templateWriter) Write(b []byte) (int, error) {
-	return tw.w.Write(b)
+	if tw.err != nil {
+		return 0, tw.err
+	}
+	n, err := tw.w.Write(b)
+	if err != nil {
+		tw.err = err
+	}
+	return n, err
+}
+
+// checkWriter returns an error if writing to the underlying io.Writer of an
+// unbuffered execution has failed. Nodes usually don't check the result of
+// their writes, so this is checked after each node to stop the execution.
+func checkWriter(ctx *ExecutionContext, writer TemplateWriter) *Error {
+	if tw, ok := writer.(*templateWriter); ok && tw.err != nil {
+		return ctx.OrigError(tw.err, nil)
+	}
+	return nil
 }
 
 type Template struct {
@@ -42,6 +62,10 @@ type Template struct {
 	parent         *Template
 	child          *Template
 	blocks         map[string]*NodeWrapper
+	blockInfos     []BlockInfo
+	blockNames     []string // names of the blocks being parsed (outermost first)
+	parseErrors    ErrorList
+	orphanEndTags  map[string]int // end-tags of failed tags, expected while recovering
 	exportedMacros map[string]*tagMacroNode
 
 	// Output
@@ -74,7 +98,7 @@ func newTemplate(set *TemplateSet, name string, isTplString bool, tpl []byte) (*
 	t.Options.Update(set.Options)
 
 	// Tokenize it
-	tokens, err := lex(name, strTpl)
+	tokens, err := lex(name, strTpl, set.delimiters)
 	if err != nil {
 		return nil, err
 	}
@@ -90,6 +114,9 @@ func newTemplate(set *TemplateSet, name string, isTplString bool, tpl []byte) (*
 	if err != nil {
 		return nil, err
 	}
+	if len(t.parseErrors) > 0 {
+		return nil, t.parseErrors
+	}
 
 	return t, nil
 }
@@ -129,7 +156,8 @@ func (tpl *Template) newContextForExecution(context Context) (*Template, *Execut
 		parent = parent.parent
 	}
 
-	// Create context if none is given
+	// Always work on a (shallow) copy of the given context so concurrent
+	// executions don't modify the caller's context or each other's
 	newContext := make(Context)
 	newContext.Update(tpl.set.Globals)
 
@@ -164,11 +192,24 @@ func (tpl *Template) newContextForExecution(context Context) (*Template, *Execut
 }
 
 func (tpl *Template) execute(context Context, writer TemplateWriter) error {
-	parent, ctx, err := tpl.newContextForExecution(context)
+	return tpl.executeCtx(nil, context, writer)
+}
+
+// executeCtx executes the template; the execution is aborted as soon as goctx
+// is done (if given).
+func (tpl *Template) executeCtx(goctx context.Context, data Context, writer TemplateWriter) error {
+	parent, ctx, err := tpl.newContextForExecution(data)
 	if err != nil {
 		return err
 	}
 
+	if goctx != nil {
+		if err := goctx.Err(); err != nil {
+			return ctx.OrigError(err, nil)
+		}
+		ctx.cancellation = newExecutionCancellation(goctx)
+	}
+
 	// Run the selected document
 	if err := parent.root.Execute(ctx, writer); err != nil {
 		return err
@@ -211,6 +252,10 @@ func (tpl *Template) ExecuteWriter(context Context, writer io.Writer) error {
 // case of an execution error because there's no intermediate buffer involved for
 // performance reasons. This is handy if you need high performance template
 // generation or if you want to manage your own pool of buffers.
+//
+// The output is written to writer while the template is being executed. If
+// writing fails, the execution is stopped and the write error is returned
+// (wrapped in an *Error).
 func (tpl *Template) ExecuteWriterUnbuffered(context Context, writer io.Writer) error {
 	return tpl.newTemplateWriterAndExecute(context, writer)
 }
@@ -225,7 +270,13 @@ func (tpl *Template) ExecuteBytes(context Context) ([]byte, error) {
 	return buffer.Bytes(), nil
 }
 
-// Executes the template and returns the rendered template as a string
+// Executes the template and returns the rendered template as a string.
+//
+// All Execute-functions work on a shallow copy of the given context merged
+// with the set's globals, so it's safe to share a context across concurrent
+// executions: assignments done by the template (e. g. by the set-tag) are
+// isolated to the execution. Values nested within the context (like maps or
+// pointers) are shared though.
 func (tpl *Template) Execute(context Context) (string, error) {
 	// Execute template
 	buffer, err := tpl.newBufferAndExecute(context)
@@ -236,6 +287,92 @@ func (tpl *Template) Execute(context Context) (string, error) {
 	return buffer.String(), nil
 }
 
+// ExecuteCtx behaves like Execute, but aborts the execution once goctx is
+// done (e. g. cancelled or its deadline exceeded). The returned error wraps
+// goctx.Err() then. goctx is checked periodically between the executed nodes
+// and loop iterations (see SetCancellationCheckInterval).
+func (tpl *Template) ExecuteCtx(goctx context.Context, data Context) (string, error) {
+	buffer := bytes.NewBuffer(make([]byte, 0, int(float64(tpl.size)*1.3)))
+	if err := tpl.executeCtx(goctx, data, buffer); err != nil {
+		return "", err
+	}
+	return buffer.String(), nil
+}
+
+// BlockInfo describes a block defined by a template (see Template.Blocks).
+// Line and Col are the position of the block-tag's name.
+type BlockInfo struct {
+	Name   string
+	Line   int
+	Col    int
+	Parent string // name of the enclosing block; empty for top-level blocks
+	// InChild is true if the template extends another template (the block
+	// overrides a block of its parent then).
+	InChild bool
+}
+
+// Blocks returns all blocks defined by this template (not by its parents) in
+// the order they appear in the template.
+func (tpl *Template) Blocks() []BlockInfo {
+	blocks := make([]BlockInfo, 0, len(tpl.blockInfos))
+	for _, info := range tpl.blockInfos {
+		info.InChild = tpl.parent != nil
+		blocks = append(blocks, info)
+	}
+	sort.SliceStable(blocks, func(i, j int) bool {
+		if blocks[i].Line != blocks[j].Line {
+			return blocks[i].Line < blocks[j].Line
+		}
+		return blocks[i].Col < blocks[j].Col
+	})
+	return blocks
+}
+
+// ExecuteBlock executes only the block with the given name and returns its
+// output. Template inheritance is resolved: the block's most derived override
+// is executed and block.super refers to the block it overrides. Tags outside
+// of the block (like macro definitions) are not executed.
+func (tpl *Template) ExecuteBlock(name string, context Context) (string, error) {
+	buffer := bytes.NewBuffer(make([]byte, 0, tpl.size))
+	if err := tpl.executeBlock(name, context, buffer); err != nil {
+		return "", err
+	}
+	return buffer.String(), nil
+}
+
+// ExecuteBlockWriter behaves like ExecuteBlock, but writes the block's output
+// to writer. Nothing is written on error.
+func (tpl *Template) ExecuteBlockWriter(name string, context Context, writer io.Writer) error {
+	buffer := bytes.NewBuffer(make([]byte, 0, tpl.size))
+	if err := tpl.executeBlock(name, context, buffer); err != nil {
+		return err
+	}
+	_, err := buffer.WriteTo(writer)
+	return err
+}
+
+func (tpl *Template) executeBlock(name string, context Context, writer TemplateWriter) error {
+	root, ctx, err := tpl.newContextForExecution(context)
+	if err != nil {
+		return err
+	}
+
+	node := &tagBlockNode{name: name}
+	if len(node.getBlockWrappers(root)) == 0 {
+		return &Error{
+			Template:  tpl,
+			Filename:  tpl.name,
+			Sender:    "executeblock",
+			OrigError: fmt.Errorf("block '%s' not found", name),
+		}
+	}
+
+	if err := node.Execute(ctx, writer); err != nil {
+		return err
+	}
+	return nil
+}
+
 func (tpl *Template) ExecuteBlocks(context Context, blocks []string) (map[string]string, error) {
 	var parents []*Template
 	result := make(map[string]string)
@@ -8,27 +8,64 @@ import (
 	"io/fs"
 	"log"
 	"net/http"
+	"net/url"
 	"os"
+	"path"
 	"path/filepath"
+	"strings"
+	"sync"
 )
 
-// FSLoader supports the fs.FS interface for loading templates
+// FSLoader supports the fs.FS interface (e. g. an embed.FS) for loading templates
 type FSLoader struct {
-	fs fs.FS
+	fs      fs.FS
+	baseDir string
+}
+
+// NewFSLoader creates a new FSLoader loading templates from fsys. Paths are
+// calculated relatively to the including template's path.
+func NewFSLoader(fsys fs.FS) *FSLoader {
+	return NewFSLoaderWithBaseDir(fsys, "")
 }
 
-func NewFSLoader(fs fs.FS) *FSLoader {
+// NewFSLoaderWithBaseDir creates a new FSLoader loading templates from fsys.
+// If a base directory is given, all paths are calculated relatively to it (and
+// templates outside of it can't be loaded), otherwise it works like
+// NewFSLoader. Paths always use forward slashes (like fs.FS does), regardless
+// of the OS.
+func NewFSLoaderWithBaseDir(fsys fs.FS, baseDir string) *FSLoader {
 	return &FSLoader{
-		fs: fs,
+		fs:      fsys,
+		baseDir: path.Join("/", filepath.ToSlash(baseDir)),
 	}
 }
 
+// Abs resolves a filename relative to the base directory (if any) or the
+// including template's path. The result is an absolute path within the
+// fs.FS (starting with a slash); absolute paths are returned as they are.
 func (l *FSLoader) Abs(base, name string) string {
-	return filepath.Join(filepath.Dir(base), name)
+	name = filepath.ToSlash(name)
+	if path.IsAbs(name) {
+		return path.Clean(name)
+	}
+	if l.baseDir != "/" || base == "" {
+		return path.Join(l.baseDir, name)
+	}
+	return path.Join(path.Dir(path.Join("/", filepath.ToSlash(base))), name)
 }
 
-func (l *FSLoader) Get(path string) (io.Reader, error) {
-	return l.fs.Open(path)
+// Get reads the path's content from the fs.FS. Paths outside of the base
+// directory are rejected.
+func (l *FSLoader) Get(name string) (io.Reader, error) {
+	name = path.Clean(path.Join("/", filepath.ToSlash(name)))
+	if l.baseDir != "/" && !strings.HasPrefix(name, l.baseDir+"/") {
+		return nil, fmt.Errorf("template path '%s' is outside of the base directory '%s'", name, l.baseDir)
+	}
+	buf, err := fs.ReadFile(l.fs, strings.TrimPrefix(name, "/"))
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(buf), nil
 }
 
 // LocalFilesystemLoader represents a local filesystem loader with basic
@@ -230,3 +267,250 @@ func (h *HttpFilesystemLoader) Get(path string) (io.Reader, error) {
 
 	return h.fs.Open(fullPath)
 }
+
+// HTTPLoader loads templates via HTTP(S) GET requests below a base URL.
+type HTTPLoader struct {
+	baseURL    *url.URL
+	baseURLErr error
+	client     *http.Client
+
+	// Header is sent along with every request (e. g. for authorization).
+	Header http.Header
+}
+
+// NewHTTPLoader creates a new HTTPLoader loading templates below baseURL
+// using client (http.DefaultClient if nil). Paths are resolved like links:
+// relatively to the including template's URL or to baseURL. Templates
+// outside of baseURL can't be loaded.
+func NewHTTPLoader(baseURL string, client *http.Client) *HTTPLoader {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	u, err := url.Parse(baseURL)
+	if err == nil && !strings.HasSuffix(u.Path, "/") {
+		// The base URL is a directory
+		u.Path += "/"
+	}
+	return &HTTPLoader{
+		baseURL:    u,
+		baseURLErr: err,
+		client:     client,
+		Header:     make(http.Header),
+	}
+}
+
+// Abs resolves name relatively to base (if it's a URL) or to the base URL.
+func (h *HTTPLoader) Abs(base, name string) string {
+	if h.baseURLErr != nil {
+		return name
+	}
+	ref, err := url.Parse(name)
+	if err != nil {
+		return name
+	}
+	if baseURL, err := url.Parse(base); err == nil && baseURL.IsAbs() {
+		return baseURL.ResolveReference(ref).String()
+	}
+	return h.baseURL.ResolveReference(ref).String()
+}
+
+// Get requests the template from the given URL. Responses with a status other
+// than 200 OK result in an error.
+func (h *HTTPLoader) Get(path string) (io.Reader, error) {
+	if h.baseURLErr != nil {
+		return nil, fmt.Errorf("invalid base URL: %w", h.baseURLErr)
+	}
+	if !strings.HasPrefix(path, h.baseURL.String()) {
+		return nil, fmt.Errorf("template URL '%s' is outside of the base URL '%s'", path, h.baseURL)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range h.Header {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("requesting template '%s' failed: %s", path, resp.Status)
+	}
+	buf, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(buf), nil
+}
+
+// MultiLoader tries multiple loaders in order and uses the first one which is
+// able to load a template (e. g. a local override directory falling back to
+// embedded defaults).
+type MultiLoader struct {
+	loaders []TemplateLoader
+
+	// Index of the loader which found the template at a given (absolute) path
+	foundMu sync.RWMutex
+	found   map[string]int
+
+	// Content read while probing the loaders in Abs, handed out by the next
+	// Get of the same path (so the template isn't fetched twice)
+	probed map[string][]byte
+}
+
+// NewMultiLoader creates a new MultiLoader trying the given loaders in order.
+func NewMultiLoader(loaders ...TemplateLoader) *MultiLoader {
+	return &MultiLoader{
+		loaders: loaders,
+		found:   make(map[string]int),
+		probed:  make(map[string][]byte),
+	}
+}
+
+// Abs returns the path as resolved by the first loader able to load the
+// template. If none of the loaders can load it, the path as resolved by the
+// first loader is returned. The loader found is remembered per path, so it
+// isn't probed again (use a new MultiLoader to pick up added templates).
+func (m *MultiLoader) Abs(base, name string) string {
+	for idx, loader := range m.loaders {
+		p := loader.Abs(base, name)
+
+		m.foundMu.RLock()
+		found, has := m.found[p]
+		m.foundMu.RUnlock()
+		if has {
+			if found == idx {
+				return p
+			}
+			if found > idx {
+				// This loader failed already when the path was resolved
+				continue
+			}
+		}
+
+		fd, err := loader.Get(p)
+		if err != nil {
+			continue
+		}
+		buf, err := io.ReadAll(fd)
+		if closer, ok := fd.(io.Closer); ok {
+			closer.Close()
+		}
+		if err != nil {
+			continue
+		}
+		m.foundMu.Lock()
+		m.found[p] = idx
+		m.probed[p] = buf
+		m.foundMu.Unlock()
+		return p
+	}
+	if len(m.loaders) > 0 {
+		return m.loaders[0].Abs(base, name)
+	}
+	return name
+}
+
+// Get loads the template through the loader which resolved the path in Abs
+// (or the first succeeding loader otherwise). If all loaders fail, the
+// returned error contains the errors of all loaders.
+func (m *MultiLoader) Get(path string) (io.Reader, error) {
+	m.foundMu.Lock()
+	buf, probed := m.probed[path]
+	delete(m.probed, path)
+	found, has := m.found[path]
+	m.foundMu.Unlock()
+	if probed {
+		return bytes.NewReader(buf), nil
+	}
+	if has {
+		if fd, err := m.loaders[found].Get(path); err == nil {
+			return fd, nil
+		}
+	}
+
+	errs := make([]string, 0, len(m.loaders))
+	for idx, loader := range m.loaders {
+		fd, err := loader.Get(path)
+		if err == nil {
+			return fd, nil
+		}
+		errs = append(errs, fmt.Sprintf("loader %d: %v", idx, err))
+	}
+	return nil, fmt.Errorf("unable to load template '%s' (%s)", path, strings.Join(errs, "; "))
+}
+
+// CachedLoader wraps another loader and keeps the loaded templates in memory
+// until they get invalidated. Note that a TemplateSet caches compiled
+// templates on its own (unless in debug mode), see TemplateSet.CleanCache.
+type CachedLoader struct {
+	inner TemplateLoader
+
+	mu    sync.RWMutex
+	cache map[string][]byte
+}
+
+// NewCachedLoader creates a new CachedLoader wrapping inner. It's returned both
+// as *CachedLoader (for invalidation) and as TemplateLoader (for NewSet).
+func NewCachedLoader(inner TemplateLoader) (*CachedLoader, TemplateLoader) {
+	loader := &CachedLoader{
+		inner: inner,
+		cache: make(map[string][]byte),
+	}
+	return loader, loader
+}
+
+// Abs delegates to the wrapped loader.
+func (c *CachedLoader) Abs(base, name string) string {
+	return c.inner.Abs(base, name)
+}
+
+// Get returns the cached template or loads (and caches) it using the wrapped
+// loader. Errors aren't cached.
+func (c *CachedLoader) Get(path string) (io.Reader, error) {
+	c.mu.RLock()
+	buf, has := c.cache[path]
+	c.mu.RUnlock()
+	if has {
+		return bytes.NewReader(buf), nil
+	}
+
+	fd, err := c.inner.Get(path)
+	if err != nil {
+		return nil, err
+	}
+	if closer, ok := fd.(io.Closer); ok {
+		defer closer.Close()
+	}
+	buf, err = io.ReadAll(fd)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.cache[path] = buf
+	c.mu.Unlock()
+
+	return bytes.NewReader(buf), nil
+}
+
+// Invalidate removes the template at the given (absolute) path from the cache.
+func (c *CachedLoader) Invalidate(path string) {
+	c.mu.Lock()
+	delete(c.cache, path)
+	c.mu.Unlock()
+}
+
+// InvalidateAll removes all templates from the cache.
+func (c *CachedLoader) InvalidateAll() {
+	c.mu.Lock()
+	c.cache = make(map[string][]byte)
+	c.mu.Unlock()
+}
@@ -0,0 +1,276 @@
+package pongo2_test
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/flosch/pongo2/v6"
+)
+
+func TestFSLoader(t *testing.T) {
+	fsys := fstest.MapFS{
+		"templates/base.html":            {Data: []byte(`<title>{% block title %}Base{% endblock %}</title>{% include "partials/footer.html" %}`)},
+		"templates/pages/child.html":     {Data: []byte(`{% extends "base.html" %}{% block title %}{{ block.super }} - Child{% endblock %}`)},
+		"templates/partials/footer.html": {Data: []byte(`<footer>{{ year }}</footer>`)},
+		"templates/escape.html":          {Data: []byte(`{% include "../secret.txt" %}`)},
+		"secret.txt":                     {Data: []byte("secret")},
+	}
+
+	set := pongo2.NewSet("fs", pongo2.NewFSLoaderWithBaseDir(fsys, "templates"))
+	tpl, err := set.FromFile("pages/child.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := tpl.Execute(pongo2.Context{"year": 2022})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "<title>Base - Child</title><footer>2022</footer>"; out != want {
+		t.Errorf("got %q, want %q", out, want)
+	}
+
+	// No escape out of the base directory
+	if _, err := set.FromFile("escape.html"); err == nil {
+		t.Error("expected an error including a template outside of the base directory")
+	}
+	if _, err := set.FromFile("../secret.txt"); err == nil {
+		t.Error("expected an error loading a template outside of the base directory")
+	}
+
+	// Without a base directory paths are relative to the including template
+	set = pongo2.NewSet("fs without base directory", pongo2.NewFSLoader(fsys))
+	tpl, err = set.FromString(`{% include "templates/partials/footer.html" %}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err = tpl.Execute(pongo2.Context{"year": 2023})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "<footer>2023</footer>"; out != want {
+		t.Errorf("got %q, want %q", out, want)
+	}
+	if _, err := set.FromFile("templates/escape.html"); err != nil {
+		t.Errorf("got %v, want the sibling of the templates directory to be included", err)
+	}
+	if _, err := set.FromFile("missing.html"); err == nil {
+		t.Error("expected an error loading a missing template")
+	}
+}
+
+func TestHTTPLoader(t *testing.T) {
+	templates := map[string]string{
+		"/tpl/base.html":            `<title>{% block title %}Base{% endblock %}</title>{% include "partials/footer.html" %}`,
+		"/tpl/child.html":           `{% extends "base.html" %}{% block title %}{{ block.super }} - Child{% endblock %}`,
+		"/tpl/partials/footer.html": `<footer>{{ year }}</footer>`,
+		"/tpl/escape.html":          `{% include "../secret.html" %}`,
+		"/secret.html":              `secret`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		tpl, has := templates[r.URL.Path]
+		if !has {
+			http.NotFound(w, r)
+			return
+		}
+		io.WriteString(w, tpl)
+	}))
+	defer server.Close()
+
+	loader := pongo2.NewHTTPLoader(server.URL+"/tpl", server.Client())
+	set := pongo2.NewSet("http", loader)
+
+	// Unauthorized
+	_, err := loader.Get(loader.Abs("", "child.html"))
+	mustEqual(t, fmt.Sprintf("%v", err), "401 Unauthorized")
+
+	loader.Header.Set("Authorization", "Bearer token")
+	tpl, err := set.FromFile("child.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := tpl.Execute(pongo2.Context{"year": 2022})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "<title>Base - Child</title><footer>2022</footer>"; out != want {
+		t.Errorf("got %q, want %q", out, want)
+	}
+
+	_, err = loader.Get(loader.Abs("", "missing.html"))
+	mustEqual(t, fmt.Sprintf("%v", err), "404 Not Found")
+
+	if _, err := set.FromFile("escape.html"); err == nil {
+		t.Error("expected an error including a template outside of the base URL")
+	}
+}
+
+func TestMultiLoader(t *testing.T) {
+	overrides := fstest.MapFS{
+		"base.html": {Data: []byte(`<title>Override</title>{% block content %}{% endblock %}`)},
+	}
+	defaults := fstest.MapFS{
+		"base.html":    {Data: []byte(`<title>Default</title>{% block content %}{% endblock %}`)},
+		"index.html":   {Data: []byte(`{% extends "base.html" %}{% block content %}{% include "footer.html" %}{% endblock %}`)},
+		"footer.html":  {Data: []byte(`<footer></footer>`)},
+		"default.html": {Data: []byte(`default`)},
+	}
+	loader := pongo2.NewMultiLoader(pongo2.NewFSLoader(overrides), pongo2.NewFSLoader(defaults))
+	set := pongo2.NewSet("multi", loader)
+
+	// Exists only in the second loader
+	tpl, err := set.FromFile("default.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := tpl.Execute(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mustEqual(t, out, "default")
+
+	// The first loader shadows the second one
+	tpl, err = set.FromFile("index.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err = tpl.Execute(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mustEqual(t, out, "<title>Override</title><footer></footer>")
+
+	// Not found by any loader
+	_, err = loader.Get(loader.Abs("", "missing.html"))
+	mustEqual(t, fmt.Sprintf("%v", err), "unable to load template '/missing.html' \\(loader 0: .*missing.html.*; loader 1: .*missing.html.*\\)")
+}
+
+type closingReader struct {
+	io.Reader
+	closed *int
+}
+
+func (r *closingReader) Close() error {
+	*r.closed++
+	return nil
+}
+
+type closingLoader struct {
+	pongo2.TemplateLoader
+	closed int
+}
+
+func (l *closingLoader) Get(path string) (io.Reader, error) {
+	fd, err := l.TemplateLoader.Get(path)
+	if err != nil {
+		return nil, err
+	}
+	return &closingReader{Reader: fd, closed: &l.closed}, nil
+}
+
+func TestMultiLoaderFetchesOnce(t *testing.T) {
+	overrides := &countingLoader{TemplateLoader: pongo2.NewFSLoader(fstest.MapFS{}), gets: make(map[string]int)}
+	defaults := &closingLoader{TemplateLoader: &countingLoader{
+		TemplateLoader: pongo2.NewFSLoader(fstest.MapFS{
+			"index.html": {Data: []byte(`index`)},
+		}),
+		gets: make(map[string]int),
+	}}
+	set := pongo2.NewSet("multi", pongo2.NewMultiLoader(overrides, defaults))
+
+	for i := 0; i < 2; i++ {
+		tpl, err := set.FromCache("index.html")
+		if err != nil {
+			t.Fatal(err)
+		}
+		out, err := tpl.Execute(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		mustEqual(t, out, "index")
+	}
+	mustEqual(t, fmt.Sprint(defaults.TemplateLoader.(*countingLoader).gets["/index.html"]), "1")
+	mustEqual(t, fmt.Sprint(overrides.gets["/index.html"]), "1")
+	mustEqual(t, fmt.Sprint(defaults.closed), "1")
+}
+
+type countingLoader struct {
+	pongo2.TemplateLoader
+	gets map[string]int
+}
+
+func (l *countingLoader) Get(path string) (io.Reader, error) {
+	l.gets[path]++
+	return l.TemplateLoader.Get(path)
+}
+
+func TestCachedLoader(t *testing.T) {
+	fsys := fstest.MapFS{
+		"index.html": {Data: []byte(`v1`)},
+		"other.html": {Data: []byte(`other`)},
+	}
+	inner := &countingLoader{TemplateLoader: pongo2.NewFSLoader(fsys), gets: make(map[string]int)}
+	cached, loader := pongo2.NewCachedLoader(inner)
+
+	read := func(name string) string {
+		fd, err := loader.Get(loader.Abs("", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		buf, err := io.ReadAll(fd)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(buf)
+	}
+
+	for i := 0; i < 3; i++ {
+		mustEqual(t, read("index.html"), "v1")
+		mustEqual(t, read("other.html"), "other")
+	}
+	mustEqual(t, fmt.Sprint(inner.gets["/index.html"]), "1")
+	mustEqual(t, fmt.Sprint(inner.gets["/other.html"]), "1")
+
+	fsys["index.html"] = &fstest.MapFile{Data: []byte(`v2`)}
+	mustEqual(t, read("index.html"), "v1")
+
+	cached.Invalidate("/index.html")
+	mustEqual(t, read("index.html"), "v2")
+	mustEqual(t, read("other.html"), "other")
+	mustEqual(t, fmt.Sprint(inner.gets["/index.html"]), "2")
+	mustEqual(t, fmt.Sprint(inner.gets["/other.html"]), "1")
+
+	cached.InvalidateAll()
+	read("index.html")
+	read("other.html")
+	mustEqual(t, fmt.Sprint(inner.gets["/index.html"]), "3")
+	mustEqual(t, fmt.Sprint(inner.gets["/other.html"]), "2")
+
+	// Errors aren't cached
+	if _, err := loader.Get("/missing.html"); err == nil {
+		t.Fatal("expected an error")
+	}
+	fsys["missing.html"] = &fstest.MapFile{Data: []byte(`found`)}
+	mustEqual(t, read("missing.html"), "found")
+}
+
+func TestCachedLoaderClosesReaders(t *testing.T) {
+	inner := &closingLoader{TemplateLoader: pongo2.NewFSLoader(fstest.MapFS{
+		"index.html": {Data: []byte(`index`)},
+	})}
+	_, loader := pongo2.NewCachedLoader(inner)
+
+	for i := 0; i < 2; i++ {
+		if _, err := loader.Get(loader.Abs("", "index.html")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mustEqual(t, fmt.Sprint(inner.closed), "1")
+}
@@ -6,6 +6,7 @@ import (
 	"io"
 	"log"
 	"os"
+	"strings"
 	"sync"
 )
 
@@ -19,6 +20,35 @@ type TemplateLoader interface {
 	Get(path string) (io.Reader, error)
 }
 
+// UndefinedBehavior defines how the variables of a template set's templates
+// are treated which are neither part of the context nor defined by the
+// template (e. g. by a for- or set-tag). Variables being part of the context
+// with a nil value are not undefined.
+type UndefinedBehavior int
+
+const (
+	// UndefinedSilent resolves undefined variables to nil (rendered empty).
+	// This is the default.
+	UndefinedSilent UndefinedBehavior = iota
+
+	// UndefinedError makes the execution fail naming the undefined variable.
+	UndefinedError
+
+	// UndefinedCustom calls the template set's UndefinedHandler to resolve
+	// undefined variables.
+	UndefinedCustom
+)
+
+// UndefinedHandlerFunction is the type of the function resolving undefined
+// variables (see UndefinedCustom). The returned value is used in place of
+// the variable; a returned error makes the execution fail.
+type UndefinedHandlerFunction func(name string) (*Value, *Error)
+
+// URLResolverFunction is the type of the function the url-tag uses to build
+// URLs. It receives the route's name and the tag's positional and keyword
+// arguments.
+type URLResolverFunction func(name string, args []*Value, kwargs map[string]*Value) (string, error)
+
 // TemplateSet allows you to create your own group of templates with their own
 // global context (which is shared among all members of the set) and their own
 // configuration.
@@ -28,7 +58,11 @@ type TemplateSet struct {
 	name    string
 	loaders []TemplateLoader
 
-	// Globals will be provided to all templates created within this template set
+	// Globals will be provided to all templates created within this template set.
+	// They're merged underneath the context given to Execute, so the values
+	// of the latter take precedence on key collisions.
+	// Globals is read on every execution without any locking; don't modify it
+	// while templates of this set are executed (from other goroutines).
 	Globals Context
 
 	// If debug is true (default false), ExecutionContext.Logf() will work and output
@@ -41,8 +75,47 @@ type TemplateSet struct {
 	// You can change the options before calling the Execute method.
 	Options *Options
 
+	// CacheBackend stores the fragments rendered by the cache-tag. Defaults to
+	// an in-memory cache holding up to DefaultCacheSize fragments. If set to
+	// nil, the cache-tag's content is rendered on every execution.
+	CacheBackend CacheBackend
+
+	// URLResolver is called by the url-tag to build URLs. The url-tag fails
+	// if it's nil (the default).
+	URLResolver URLResolverFunction
+
+	// Translator translates the messages of the trans- and blocktrans-tag.
+	// If it's nil (the default), the messages are output untranslated.
+	Translator Translator
+
+	// NilSafeAttributes makes accessing an attribute, key, index or method on
+	// a nil value (like a nil pointer, map, slice or interface) within a
+	// variable chain (e. g. {{ a.b.c }} with a.b being nil) result in a nil
+	// value (rendered empty) instead of an error. Defaults to false.
+	NilSafeAttributes bool
+
+	// If CollectParseErrors is true, the parser continues after an error
+	// with the next tag or variable, so all (recoverable) errors of a template
+	// are returned at once as an ErrorList. Otherwise (the default) parsing
+	// stops at the first error, which is returned as *Error.
+	CollectParseErrors bool
+
+	// If RecoverFilterPanics is true, a panic within a filter called by one
+	// of this set's templates is turned into an *Error instead of crashing
+	// the whole rendering process, like SetFilterPanicRecovery does globally.
+	// Defaults to false.
+	RecoverFilterPanics bool
+
+	// UndefinedBehavior defines how undefined variables are treated
+	// (UndefinedSilent by default). UndefinedHandler is used in the
+	// UndefinedCustom mode.
+	UndefinedBehavior UndefinedBehavior
+	UndefinedHandler  UndefinedHandlerFunction
+
 	// Sandbox features
 	// - Disallow access to specific tags and/or filters (using BanTag() and BanFilter())
+	// - Allow only specific tags (using BanAllTagsExcept())
+	// - Disallow the tags accessing the file system (using Sandboxed)
 	//
 	// For efficiency reasons you can ban tags/filters only *before* you have
 	// added your first template to the set (restrictions are statically checked).
@@ -50,6 +123,15 @@ type TemplateSet struct {
 	firstTemplateCreated bool
 	bannedTags           map[string]bool
 	bannedFilters        map[string]bool
+	allowedTags          map[string]bool // if not nil, all other tags are banned (see BanAllTagsExcept)
+
+	// Sandboxed bans all tags accessing the file system (see SandboxedTags)
+	// additionally to the ones banned by BanTag() and BanAllTagsExcept().
+	// Like the other restrictions it's checked at parse time.
+	Sandboxed bool
+
+	// Delimiters of variables, tags and comments (see SetDelimiters())
+	delimiters *delimiters
 
 	// Template cache (for FromCache())
 	templateCache      map[string]*Template
@@ -72,6 +154,8 @@ func NewSet(name string, loaders ...TemplateLoader) *TemplateSet {
 		bannedFilters: make(map[string]bool),
 		templateCache: make(map[string]*Template),
 		Options:       newOptions(),
+		delimiters:    defaultDelimiters,
+		CacheBackend:  NewMemoryCache(DefaultCacheSize),
 	}
 }
 
@@ -95,9 +179,63 @@ func (set *TemplateSet) resolveFilenameForLoader(loader TemplateLoader, tpl *Tem
 	return loader.Abs(name, path)
 }
 
+// SetDelimiters changes the delimiters of variables (default "{{" and "}}")
+// and tags (default "{%" and "%}") for all templates of this set created
+// afterwards. The template cache is cleaned.
+func (set *TemplateSet) SetDelimiters(varStart, varEnd, tagStart, tagEnd string) error {
+	d := set.delimiters
+	return set.setDelimiters(varStart, varEnd, tagStart, tagEnd, d.commentStart, d.commentEnd)
+}
+
+// SetCommentDelimiters changes the delimiters of comments (default "{#" and "#}")
+// for all templates of this set created afterwards. The template cache is cleaned.
+func (set *TemplateSet) SetCommentDelimiters(commentStart, commentEnd string) error {
+	d := set.delimiters
+	return set.setDelimiters(d.varStart, d.varEnd, d.tagStart, d.tagEnd, commentStart, commentEnd)
+}
+
+func (set *TemplateSet) setDelimiters(varStart, varEnd, tagStart, tagEnd, commentStart, commentEnd string) error {
+	starts := []string{varStart, tagStart, commentStart}
+	for _, delim := range append(starts, varEnd, tagEnd, commentEnd) {
+		if delim == "" || strings.ContainsAny(delim, tokenSpaceChars) {
+			return fmt.Errorf("delimiter '%s' must not be empty or contain whitespace", delim)
+		}
+	}
+	for i := range starts {
+		for j := range starts {
+			if i != j && strings.HasPrefix(starts[i], starts[j]) {
+				return fmt.Errorf("start delimiters '%s' and '%s' are ambiguous", starts[j], starts[i])
+			}
+		}
+	}
+
+	set.delimiters = newDelimiters(varStart, varEnd, tagStart, tagEnd, commentStart, commentEnd)
+	set.CleanCache()
+
+	return nil
+}
+
+// resolveUndefined resolves the undefined variable name according to the
+// set's UndefinedBehavior.
+func (set *TemplateSet) resolveUndefined(name string) (*Value, error) {
+	switch set.UndefinedBehavior {
+	case UndefinedError:
+		return nil, fmt.Errorf("variable '%s' is undefined", name)
+	case UndefinedCustom:
+		if set.UndefinedHandler != nil {
+			value, err := set.UndefinedHandler(name)
+			if err != nil {
+				return nil, err
+			}
+			return value, nil
+		}
+	}
+	return AsValue(nil), nil
+}
+
 // BanTag bans a specific tag for this template set. See more in the documentation for TemplateSet.
 func (set *TemplateSet) BanTag(name string) error {
-	_, has := tags[name]
+	has := TagExists(name)
 	if !has {
 		return fmt.Errorf("tag '%s' not found", name)
 	}
@@ -113,6 +251,51 @@ func (set *TemplateSet) BanTag(name string) error {
 	return nil
 }
 
+// BanAllTagsExcept bans all tags except the given ones for this template set,
+// including tags registered later on. See more in the documentation for TemplateSet.
+func (set *TemplateSet) BanAllTagsExcept(allow ...string) error {
+	if set.firstTemplateCreated {
+		return errors.New("you cannot ban any tags after you've added your first template to your template set")
+	}
+	allowedTags := make(map[string]bool, len(allow))
+	for _, name := range allow {
+		if !TagExists(name) {
+			return fmt.Errorf("tag '%s' not found", name)
+		}
+		allowedTags[name] = true
+	}
+	set.allowedTags = allowedTags
+
+	return nil
+}
+
+// SandboxedTags are the tags banned in a sandboxed template set (see
+// TemplateSet.Sandboxed) since they're accessing the file system.
+var SandboxedTags = []string{"extends", "import", "include", "include_raw", "ssi"}
+
+// isTagBanned returns true if the given tag must not be used within this template set.
+func (set *TemplateSet) isTagBanned(name string) bool {
+	if set.bannedTags[name] {
+		return true
+	}
+	if set.allowedTags != nil && !set.allowedTags[name] {
+		return true
+	}
+	if set.Sandboxed {
+		for _, sandboxedTag := range SandboxedTags {
+			if name == sandboxedTag {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isFilterBanned returns true if the given filter must not be used within this template set.
+func (set *TemplateSet) isFilterBanned(name string) bool {
+	return set.bannedFilters[name]
+}
+
 // BanFilter bans a specific filter for this template set. See more in the documentation for TemplateSet.
 func (set *TemplateSet) BanFilter(name string) error {
 	_, has := filters.Load(name)
@@ -7,4 +7,8 @@
 {% endautoescape %}
 {% autoescape off %}
 {{ "<script>alert('xss');</script>"|escape }}
-{% endautoescape %}
\ No newline at end of file
+{% endautoescape %}
+nested
+{% autoescape on %}{{ "<1>" }}{% autoescape off %}{{ "<2>" }}{% autoescape on %}{{ "<3>" }}{{ "<3>"|safe }}{% endautoescape %}{{ "<2>" }}{% endautoescape %}{{ "<1>" }}{{ "<1>"|safe }}{% endautoescape %}
+{% autoescape off %}{{ "<1>" }}{% autoescape on %}{{ "<2>" }}{% autoescape off %}{{ "<3>" }}{% endautoescape %}{{ "<2>" }}{% endautoescape %}{{ "<1>" }}{% endautoescape %}
+{% for i in "ab" %}{% autoescape off %}{{ "<" }}{{ i }}{% break %}{% endautoescape %}{% endfor %}{{ "<" }}
\ No newline at end of file
@@ -0,0 +1,8 @@
+{% for i in simple.multiple_item_list %}{% if i > 5 %}{% break %}{% endif %}{{ i }} {% endfor %}
+{% for i in simple.multiple_item_list %}{% if i % 2 == 1 %}{% continue %}{% endif %}{{ forloop.Counter }}:{{ i }} {% endfor %}
+{% for i in simple.multiple_item_list %}{% break %}{% empty %}empty{% endfor %}.
+{% for i in simple.one_item_list %}{% for j in simple.multiple_item_list %}{% if j > 2 %}{% break %}{% endif %}{{ i }}-{{ j }} {% endfor %}after inner {% endfor %}
+{% for i in simple.multiple_item_list %}{% if forloop.Counter > 3 %}{% break %}{% endif %}{{ forloop.Counter }}/{{ forloop.Revcounter }} {% endfor %}
+{% for i in simple.multiple_item_list %}{% spaceless %}<b> {{ i }} </b> {% if i == 2 %}{% break %}{% endif %}{% endspaceless %}{% endfor %}
+{% for i in "abc" %}{% filter upper %}{{ i }}{% if i == "b" %}{% continue %}{% endif %}!{% endfilter %}{% endfor %}
+{% for k, v in simple.strmap sorted %}{% if k == "bcd" %}{% continue %}{% endif %}{% if k == "ukq" %}{% break %}{% endif %}{{ k }}={{ v }} {% endfor %}
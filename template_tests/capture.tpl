@@ -0,0 +1,5 @@
+{% capture as tooltip %}<b>{{ simple.name|title }}</b> ({{ simple.xss }}){% endcapture %}[{{ tooltip }}] [{{ tooltip }}] [{{ tooltip|striptags }}] <span title="{{ tooltip|length }}"></span>
+{% capture as items %}{% for i in simple.multiple_item_list %}{% if i > 3 %}{% break %}{% endif %}{{ i }},{% endfor %}{% endcapture %}{{ items }}{{ items }}
+{% for i in simple.multiple_item_list %}{% capture as out %}<{{ i }}>{% if i == 2 %}{% break %}{% endif %}{% endcapture %}{{ out }}{% endfor %}
+{% capture as empty %}{% endcapture %}'{{ empty }}' {% if empty %}not empty{% else %}empty{% endif %}
+{% autoescape off %}{% capture as raw %}{{ simple.xss }}{% endcapture %}{% endautoescape %}{{ raw }}
@@ -0,0 +1,18 @@
+{% collapse %}
+server {
+    listen   80;
+	server_name  example.com   www.example.com;
+
+    return 301 "https://$host    /"  'a  \'  b';
+}
+{% endcollapse %}
+{% collapse "" %}[ {{ simple.name }}   is
+    {{ simple.number }} ]{% endcollapse %}
+{% collapse "_" %}a  b
+c{% endcollapse %}
+{% for i in simple.multiple_item_list %}{% collapse %}{{ i }}   {% if i == 3 %}{% break %}{% endif %}{% endcollapse %}{% endfor %}
+{% collapse %}It's   a
+
+   test{% endcollapse %}
+{% collapse %}say "a  b
+   c"  and 'x   y'{% endcollapse %}
\ No newline at end of file
@@ -19,4 +19,7 @@
 '{% cycle "item1" simple.name simple.number as cycleitem silent %}'
 '{{ cycleitem }}'
 '{% cycle cycleitem %}'
-'{{ cycleitem }}'
\ No newline at end of file
+'{{ cycleitem }}'
+{% for row in "ab" %}{% for i in "123" %}{% cycle "odd" "even" %} {% endfor %}|{% endfor %}
+{% for row in "ab" %}{% for i in "123" %}{% cycle "odd" "even" as rowclass silent %}<tr class="{{ rowclass }}">{% endfor %}|{% endfor %}
+{% for i in "1234" %}{% cycle "a" "b" "c" as x %}{% cycle x %}={{ x }} {% endfor %}
@@ -71,4 +71,26 @@ issue #64 (uint comparison with int const)
 string concatenation
 {{ "a" + "b" }}
 {{ 1 + "a" }}
-{{ "a" + "1" }}
\ No newline at end of file
+{{ "a" + "1" }}
+conditional expression
+{{ simple.bool_true ? "on" : "off" }}
+{{ simple.bool_false ? "on" : "off" }}
+{{ simple.uint > 5 ? "greater" : "less" }}
+{{ 1 + 1 == 2 ? 10 + 5 : 20 }}
+{{ simple.bool_false || simple.uint == 8 ? "yes" : "no" }}
+{{ simple.bool_false ? "a" : simple.nil ? "b" : "c" }}
+{{ simple.bool_true ? simple.bool_false ? "x" : "y" : "z" }}
+{{ (simple.bool_true ? 2 : 3) * 10 }}
+{{ simple.str ? (simple.str|upper) : "-" }}
+{{ simple.str|length > 3 ? simple.str|truncatechars:4 : "short" }}
+{% if simple.bool_false ? false : true %}if ok{% endif %}
+
+null-coalescing operator
+{{ simple.nil ?? "fallback" }}
+'{{ simple.emptystring_missing ?? simple.nil ?? "third" }}'
+'{{ simple.str ?? "fallback" }}'
+'{{ "" ?? "fallback" }}' '{{ simple.bool_false ?? "fallback" }}' '{{ 0 ?? "fallback" }}'
+{{ simple.nil ?? simple.uint + 1 }}
+{{ simple.nil ?? simple.bool_false ? "yes" : "no" }}
+{{ simple.bool_false || simple.nil ?? "or binds tighter" }}
+{{ simple.nil ?? simple.str|upper }}
@@ -0,0 +1,3 @@
+{% extends "extends_super2.tpl" %}
+
+{% block content %}[{{ block.super }}]extends-level-3{% endblock %}
\ No newline at end of file
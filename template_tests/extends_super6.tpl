@@ -0,0 +1,3 @@
+{% extends "inheritance/base.tpl" %}
+
+{% block content %}{{ block.super|upper }} and {{ block.super }}{% endblock %}
\ No newline at end of file
@@ -5,11 +5,25 @@ add
 {{ 5|add:"test" }}
 {{ "hello "|add:"john doe" }}
 {{ "hello "|add:simple.name }}
+{{ "2"|add:"3" }} {{ "2"|add:3 }} {{ 2.5|add:"1" }} {{ "2.5"|add:"1" }} {{ "-2"|add:simple.number }}
+{{ simple.one_item_list|add:simple.misc_list|join:"," }} {{ simple.misc_list|add:simple.one_item_list|length }} {{ simple.misc_list|length }}
 
 addslashes
 {{ "plain text"|addslashes|safe }}
 {{ simple.escape_text|addslashes|safe }}
 
+apply
+{{ simple.name|apply:"upper" }} {% with f="capfirst" %}{{ simple.name|apply:f }}{% endwith %} {{ simple.multiple_item_list|apply:"length" }}
+
+b64encode/b64decode
+{{ simple.name|b64encode }}
+{{ simple.bytes|b64encode }}
+{{ simple.bytes|b64encode|b64decode }}
+{{ "??>"|b64encode }}
+{{ "??>"|b64encode:"url" }}
+{{ "??>"|b64encode:"url"|b64decode:"url" }}
+{{ simple.chinese_hello_world|b64encode|b64decode }}
+
 capfirst
 {{ ""|capfirst }}
 {{ 5|capfirst }}
@@ -31,6 +45,13 @@ default_if_none
 {{ simple.nothing|default_if_none:"n/a" }}
 {{ ""|default_if_none:"n/a" }}
 {{ nil|default_if_none:"n/a" }}
+{{ 0|default_if_none:"n/a" }} {{ simple.bool_false|default_if_none:"n/a" }} {{ simple.nil|default_if_none:"n/a" }} {{ simple.nil|default:"n/a" }} {{ 0|default:"n/a" }}
+
+get
+{{ simple.strmap|get:"abc" }} {{ simple.strmap|get:"missing"|default:"fallback" }} {{ simple.strmap|get:5|default:"wrong type" }}
+{{ simple.intmap|get:5 }} {{ simple.intmap|get:"5"|default:"none" }} {% with k=2 %}{{ simple.intmap|get:k }}{% endwith %}
+{{ complex.comments.0|get:"Text"|safe }} {{ complex.comments.0|get:"Author"|get:"Name" }} {{ complex.comments.0|get:"Missing"|default:"none" }}
+{{ simple.nil|get:"x"|default:"nil" }} {{ simple.str|get:"x"|default:"no map" }}
 
 get_digit
 {{ 1234567890|get_digit:0 }}
@@ -48,6 +69,10 @@ escape
 {{ "<script>"|safe|escape }}
 {{ "<script>"|safe|e }}
 
+escape_once/force_escape
+{{ "a &amp; b &lt; c & <d>"|escape_once }} {{ "&copy; &#169; &#xA9; &nope"|escape_once }}
+{{ "<b>"|safe|force_escape }} {{ "&amp;"|force_escape }}
+
 title
 {{ ""|title }}
 {{ 5|title }}
@@ -75,6 +100,13 @@ divisibleby
 striptags
 {{ "<strong><i>Hello!</i></strong>"|striptags|safe }}
 
+regex_replace
+{{ "a1b22c333"|regex_replace:"[0-9]+/#" }}
+{{ simple.name|regex_replace:"([a-z]+) ([a-z]+)/${2}, $1" }}
+{{ "a/b/c"|regex_replace:"\\//-" }}
+{{ "2024-01-31"|regex_replace:"(\\d+)-(\\d+)-(\\d+)","$3.$2.$1" }}
+{{ simple.number|regex_replace:"^4/x" }}
+
 removetags
 {{ "<strong><i>Hello!</i></strong>"|removetags:"i"|safe }}
 
@@ -86,6 +118,8 @@ yesno
 {{ simple.bool_true|yesno:"ja,nein,vielleicht" }}
 {{ simple.bool_false|yesno:"ja,nein,vielleicht" }}
 {{ simple.nothing|yesno:"ja,nein" }}
+{{ simple.nil|yesno:"Yes,No,Maybe" }} {{ simple.bool_true|yesno:"Yes,No" }} {{ simple.bool_false|yesno:"Yes,No" }}
+{{ 1|yesno }} {{ 0|yesno }} {{ ""|yesno }} {{ simple.str|yesno }} {{ simple.multiple_item_list|yesno:"full,empty" }}
 
 pluralize
 customer{{ 0|pluralize }}
@@ -97,6 +131,10 @@ cherr{{ 2|pluralize:"y,ies" }}
 walrus{{ 0|pluralize:"es" }}
 walrus{{ 1|pluralize:"es" }}
 walrus{{ simple.number|pluralize:"es" }}
+{{ 1 }} {{ 1|pluralize:"person,people" }}, {{ 3 }} {{ 3|pluralize:"person,people" }}, 0 {{ 0|pluralize:"person,people" }}
+{{ 1.5|pluralize }} {{ 1.0|pluralize }}
+item{{ simple.multiple_item_list|pluralize }} item{{ simple.one_item_list|pluralize }} item{{ simple.strmap|pluralize }}
+customer{{ "2"|pluralize }} customer{{ "1"|pluralize }} cherr{{ "1.5"|pluralize:"y,ies" }}
 
 random
 {{ 5|random }}
@@ -104,6 +142,13 @@ random
 {{ "h"|random }}
 {{ simple.one_item_list|random }}
 
+range
+{% for i in 1|range:6 %}{{ i }}{% if not forloop.Last %},{% endif %}{% endfor %}
+{% for i in 0|range:10,3 %}{{ i }}{% if not forloop.Last %},{% endif %}{% endfor %}
+{% with step=-2 %}{% for i in 10|range:0,step %}{{ i }}{% if not forloop.Last %},{% endif %}{% endfor %}{% endwith %}
+{% for i in 5|range:5 %}{{ i }}{% empty %}empty{% endfor %} {% for i in 5|range:1 %}{{ i }}{% empty %}empty{% endfor %}
+{{ 1|range:simple.number|length }}
+
 first
 {{ "Test"|first }}
 {{ complex.comments|first }}
@@ -111,6 +156,7 @@ first
 {{ true|first }}
 {{ nothing|first }}
 {{ simple.chinese_hello_world|first }}
+{{ simple.strmap|first }} {{ simple.intmap|first }} {{ simple.emptymap|first|default_if_none:"nil" }} {{ ""|first|default_if_none:"nil" }} {{ simple.multiple_item_list|slice:":0"|first|default_if_none:"nil" }} {{ nothing|first|default_if_none:"nil" }} {{ simple.misc_list|first }}
 
 last
 {{ "Test"|last }}
@@ -119,6 +165,7 @@ last
 {{ true|last }}
 {{ nothing|last }}
 {{ simple.chinese_hello_world|last }}
+{{ simple.strmap|last }} {{ simple.intmap|last }} {{ simple.emptymap|last|default_if_none:"nil" }} {{ ""|last|default_if_none:"nil" }} {{ nothing|last|default_if_none:"nil" }} {{ simple.misc_list|last }}
 
 urlencode
 {{ "http://www.example.org/foo?a=b&c=d"|urlencode }}
@@ -128,6 +175,11 @@ linebreaksbr
 {{ ""|linebreaksbr }}
 {{ "hallo"|linebreaksbr }}
 
+nl2br
+{{ simple.newline_text|nl2br }}
+{{ ""|nl2br }}|{{ simple.xss|nl2br }}
+{% autoescape off %}{{ simple.xss|nl2br }}{% endautoescape %}
+
 length_is
 {{ simple.name|length_is:8 }}
 {{ simple.name|length_is:10 }}
@@ -137,6 +189,14 @@ length_is
 {{ simple.chinese_hello_world|length_is:4 }}
 {{ simple.chinese_hello_world|length_is:3 }}
 {{ simple.chinese_hello_world|length_is:5 }}
+{{ simple.multiple_item_list|length_is:10 }} {{ simple.strmap|length_is:6 }} {{ simple.nil|length_is:0 }} {{ simple.nil|length_is:1 }}
+{% if simple.misc_list|length_is:4 %}four items{% endif %}
+
+isempty
+{{ ""|isempty }} {{ "a"|isempty }} {{ simple.nil|isempty }} {{ nothing|isempty }}
+{{ simple.multiple_item_list|isempty }} {{ simple.multiple_item_list|slice:":0"|isempty }}
+{{ simple.strmap|isempty }} {{ simple.emptymap|isempty }} {{ 0|isempty }} {{ simple.bool_false|isempty }}
+{% if simple.nil|isempty %}nil is empty{% endif %}{% if !(simple.str|isempty) %}, str is not{% endif %}
 
 integer
 {{ "foobar"|integer }}
@@ -176,14 +236,49 @@ floatformat
 
 join
 {{ simple.misc_list|join:", " }}
+{{ ["Alice", "Bob", "Carol"]|join:", "," and " }}|{{ ["Alice", "Bob"]|join:", "," and " }}|{{ ["Alice"]|join:", "," and " }}|{{ simple.multiple_item_list|slice:":0"|join:", "," and " }}|{{ "abc"|join:"",", " }}
+
+json
+{{ simple.misc_list|json }}
+{{ simple.strmap|json }}
+{{ simple.intmap|json:2 }}
+{{ complex.comments.0.Author|json }}
+{{ complex.comments.0.Author|json:"4" }}
+{{ simple.xss|json }}
+{{ simple.nil|json }}
+{{ "it's </script>"|json }}
+
+sort
+{{ simple.unsorted_int_list|sort|join:"," }}
+{{ simple.unsorted_int_list|sort:"-"|join:"," }}
+{{ "pear,apple,fig,banana"|split:","|sort|join:"," }}
+{{ simple.strmap|sort|join:"," }}
+{{ simple.intmap|sort:"-"|join:"," }}
+{% for c in complex.comments2|sort:"Date" %}{{ c.Date|date:"2006" }}-{{ c.Author.Name }} {% endfor %}
+{% for c in complex.comments2|sort:"-Date" %}{{ c.Date|date:"2006" }}-{{ c.Author.Name }} {% endfor %}
+{{ simple.name|sort }}
 
 split
 {{ "Hello, 99, 3.140000, good"|split:", "|join:", " }}
+{{ "a::b::c"|split:"::"|join:"," }}
+{{ "a::b::c"|split:"::"|length }}
+{{ ""|split:","|length }}
+{{ simple.nil|split:","|length }}
+{{ simple.number|split:""|join:"," }}
+
+trim
+'{{ "  Hello World   "|trim }}'
+'{{ "--==Hello==--"|trim:"-=" }}'
+'{{ "xxHelloxx"|trim:"x" }}'
+'{{ simple.number|trim:"4" }}'
 
 stringformat
 {{ simple.float|stringformat:"%.2f" }}
 {{ simple.uint|stringformat:"Test: %d" }}
 {{ simple.chinese_hello_world|stringformat:"Chinese: %s" }}
+{{ simple.uint|stringformat:"05d" }} {{ "42"|stringformat:"d" }} {{ simple.float|stringformat:"08.3f" }} {{ simple.uint|stringformat:".2f" }} {{ "3.14159"|stringformat:"0.1f" }}
+'{{ simple.name|stringformat:"10s" }}' '{{ simple.name|stringformat:"-10s" }}' {{ 255|stringformat:"x" }} {{ 255|stringformat:"X" }} {{ 8|stringformat:"o" }} {{ 1234.5|stringformat:"e" }}
+{{ "50%!"|stringformat:"s" }} {{ "50%!"|stringformat:"[%s]" }} {{ 50|stringformat:"%d%%" }} {{ "abc"|stringformat:"%d" }} {{ 3|stringformat:"%.1f" }}
 
 make_list
 {{ simple.name|make_list|join:", " }}
@@ -198,26 +293,43 @@ center
 '{{ "test2"|center:20 }}'
 {{ "test2"|center:20|length }}
 '{{ simple.chinese_hello_world|center:20 }}'
+'{{ simple.chinese_hello_world|center:20,"wide" }}'
+'{{ "ab"|center:7,"wide" }}'
 
 ljust
 '{{ "test"|ljust:"2" }}'
 '{{ "test"|ljust:"20" }}'
 {{ "test"|ljust:"20"|length }}
 '{{ simple.chinese_hello_world|ljust:10 }}'
+'{{ simple.chinese_hello_world|ljust:10,"wide" }}'
+'{{ "ab"|ljust:5,"wide" }}'
 
 rjust
 '{{ "test"|rjust:"2" }}'
 '{{ "test"|rjust:"20" }}'
 {{ "test"|rjust:"20"|length }}
 '{{ simple.chinese_hello_world|rjust:10 }}'
+'{{ simple.chinese_hello_world|rjust:10,"wide" }}'
+'{{ simple.chinese_hello_world|rjust:5,"wide" }}'
 
 wordcount
 {{ ""|wordcount }}
 {% filter wordcount %}{% lorem 25 w %}{% endfilter %}
+{{ "one two three four"|wordcount }} {{ "one two three four"|wordcount:"spaces" }} {{ "one two three four"|wordcount:"chars" }}
+{{ "我喜欢学习中文。"|wordcount }} {{ "我喜欢学习中文。"|wordcount:"spaces" }} {{ "我喜欢学习中文。"|wordcount:"chars" }}
+{{ simple.chinese_hello_world|wordcount }} {{ "Go 语言 rocks"|wordcount }} {{ "Go 语言 rocks"|wordcount:"spaces" }}
 
 wordwrap
 {{ ""|wordwrap:2 }}
-{% filter wordwrap:5 %}{% lorem 26 w %}{% endfilter %}
+{% filter wordwrap:30 %}{% lorem 26 w %}{% endfilter %}
+{{ "Joel is a slug"|wordwrap:5 }}
+{{ "a supercalifragilistic word"|wordwrap:6 }}
+{{ "exactly ten"|wordwrap:11 }}|{{ "käse käse käse"|wordwrap:9 }}
+
+group_by
+{% for group in complex.comments|group_by:"Date" %}{{ group.grouper|date:"2006" }}: {% for c in group.list %}{{ c.Author.Name }} {% endfor %}| {% endfor %}
+{% for group in complex.comments|group_by:"Missing" %}{{ group.grouper|default:"none" }}: {{ group.list|length }} {% endfor %}
+{% for group in "a,b,a"|split:","|group_by:"x" %}{{ group.list|join:"," }}{% endfor %}
 
 iriencode
 {{ "?foo=123&bar=yes"|iriencode }}
@@ -233,6 +345,7 @@ linenumbers
 
 phone2numeric
 {{ "999-PONGO2"|phone2numeric }}
+{{ "1-800-COLLECT"|phone2numeric }} {{ "+1 (800) Flo-sch.9, ext. #42"|phone2numeric }} {{ "Größe"|phone2numeric }}
 
 truncatewords
 {% filter truncatewords:9 %}{% lorem 25 w %}{% endfilter %}
@@ -278,6 +391,15 @@ Please mail me at demo@example.com or visit mit on:
 
 escapejs
 {{ simple.escape_js_test|escapejs|safe }}
+{{ simple.escape_js_test|escapejs }}
+{{ "line separator paragraph"|escapejs }}
+{{ "</script><b>&amp;`x`</b>"|escapejs }}
+{{ "smile 😀"|escapejs }}
+
+slugify_unicode
+{{ "Müller"|slugify_unicode }} {{ "  Crème Brûlée, à la carte!  "|slugify_unicode }} {{ "Straße Ærø"|slugify_unicode }}
+{{ "I ❤ Go 🎉 2024"|slugify_unicode }}|{{ "🎉"|slugify_unicode }}|{{ "already-a_slug"|slugify_unicode }}
+{{ "Müller"|slugify_unicode:true }} {{ "Привет, мир!"|slugify_unicode:true }} {{ "Привет, мир!"|slugify_unicode }}|{{ "東京 タワー"|slugify_unicode:true }}
 
 slice
 {{ simple.multiple_item_list|slice:":99"|join:"," }}
@@ -320,3 +442,104 @@ truncatewords_html
 {{ "<p>This </a>is a long test, which will be cutted after some words.</p>"|truncatewords_html:5 }}
 {{ "<p>This is a long test which will be cutted after some words.</p>"|truncatewords_html:2 }}
 {{ "<p>This is a long test which will be cutted after some words.</p>"|truncatewords_html:0 }}
+
+unique
+{{ simple.multiple_item_list|unique|join:"," }}
+{{ "b,a,b,c,a"|split:","|unique|join:"," }}
+{{ ["x", "y", "x"]|unique|join:"," }}
+{{ [simple.one_item_list, simple.multiple_item_list, simple.one_item_list]|unique|length }}
+{% for c in complex.comments|unique:"Date" %}{{ c.Author.Name }} {% endfor %}
+
+pluck
+{{ complex.comments|pluck:"Author.Name"|join:"," }}
+{{ complex.comments|pluck:"Date.Year"|join:"," }}
+{{ complex.comments|group_by:"Date"|pluck:"grouper.Year"|join:"," }}
+{{ complex.comments|pluck:"Missing"|length }}
+{% for v in complex.comments|pluck:"Missing" %}{{ v|default:"nil" }} {% endfor %}
+
+batch
+{% for row in simple.multiple_item_list|batch:5 %}{{ row|join:"," }}|{% endfor %}
+{% for row in simple.multiple_item_list|batch:3 %}{{ row|join:"," }}|{% endfor %}
+{% for row in simple.multiple_item_list|batch:4,0 %}{{ row|join:"," }}|{% endfor %}
+{% for row in "abcde"|batch:2,"-" %}{{ row|join:"," }}|{% endfor %}
+{{ simple.one_item_list|batch:10|length }}
+{% for row in simple.multiple_item_list|batch:100000000000000 %}{{ row|join:"," }}|{% endfor %} {{ simple.multiple_item_list|batch:9223372036854775807|length }} {% for row in "ab"|batch:100000,"-" %}{{ row|length }}{% endfor %}
+
+date
+{{ simple.time1|date:"2006-01-02 15:04 MST" }}
+{{ simple.time1|date:"2006-01-02 15:04 MST","America/New_York" }}
+{{ simple.time1|date:"2006-01-02 15:04 MST","Europe/Berlin" }}
+{{ simple.time1|date:"django:Y-m-d H:i:s" }}
+{{ simple.time1|date:"django:D, jS F Y, g:i a","Asia/Tokyo" }}
+{{ simple.time1|date:"django:l \\t\\h\\e jS" }}
+{{ simple.time1|date:"django:c" }}
+{{ simple.time1|date:"django:r" }}
+{{ simple.time1|date:"django:U" }}
+{{ simple.time2|date:"django:z W t L u N" }}
+{{ simple.time1|time:"django:H:i T","America/Los_Angeles" }}
+{{ simple.time1|date:"3:4" }} {{ simple.time1|date:"2" }} {{ simple.time1|date:"1/2" }} {{ simple.time1|time:"3:4:5" }}
+
+timesince/timeuntil
+{{ simple.time2|timesince:simple.time1 }}
+{{ simple.time1|timeuntil:simple.time2 }}
+{{ simple.time1|timesince:simple.time2 }}
+{{ simple.time2|timeuntil:simple.time1 }}
+{{ simple.time1|timesince:simple.time1 }}
+
+duration
+{{ simple.duration }}
+{{ simple.negative_duration }}
+{{ simple.duration|duration }}
+{{ simple.negative_duration|duration }}
+{{ simple.zero_duration|duration }}
+{{ "1h1s"|duration }}
+{{ simple.duration|duration:"days" }}
+{{ simple.duration|duration:"hours" }}
+{{ simple.duration|duration:"minutes" }}
+{{ simple.duration|duration:"seconds" }}
+{{ simple.duration|duration:"milliseconds" }}
+{{ simple.negative_duration|duration:"hours" }}
+{{ simple.zero_duration|duration:"minutes" }}
+
+number_format
+{{ 1234567.5|number_format:2 }}
+{{ 1234567.5|number_format }}
+{{ 1234567|number_format }}
+{{ 123|number_format:2 }}
+{{ "-1234567.891"|number_format:2 }}
+{{ "-0.001"|number_format:2 }}
+{{ "-1000"|number_format }}
+{{ simple.uint|number_format:1 }}
+{{ 9223372036854775807|number_format }}
+{{ 1234567.891|number_format:2,",","." }}
+{{ 1234567.891|number_format:3,"."," " }}
+{{ 1234567|number_format:0,".","" }}
+
+truncatehtml
+{{ "<p><b>Hello World</b>, this is pongo2.</p>"|truncatehtml:5 }}
+{{ "<p><b>Hello World</b>, this is pongo2.</p>"|truncatehtml:11 }}
+{{ "<p><b>Hello World</b>, this is pongo2.</p>"|truncatehtml:100 }}
+{{ "<div><p>Line 1<br>Line 2<br/>Line 3<img src=\"x.png\" /> end</p></div>"|truncatehtml:13 }}
+{{ "<p>Fish &amp; Chips &amp; more</p>"|truncatehtml:6 }}
+{{ "<!-- comment --><ul><li>one</li><li>two</li></ul>"|truncatehtml:4 }}
+{{ "<p><i>abc</i></p>"|truncatehtml:3 }}
+
+filesizeformat
+{{ 0|filesizeformat }}
+{{ 1|filesizeformat }}
+{{ 512|filesizeformat }}
+{{ 999|filesizeformat }}
+{{ 1000|filesizeformat }}
+{{ 1023|filesizeformat:"binary" }}
+{{ 1024|filesizeformat:"binary" }}
+{{ 1536|filesizeformat:"binary" }}
+{{ 1536|filesizeformat:"decimal" }}
+{{ 1000000|filesizeformat }}
+{{ 1048576|filesizeformat:"binary" }}
+{{ 1073741824|filesizeformat:"binary" }}
+{{ 2500000000.0|filesizeformat }}
+{{ "1000000000000000000000"|filesizeformat }}
+{{ "-2048"|filesizeformat:"binary" }}
+{{ 999999|filesizeformat }}
+{{ 999949|filesizeformat }}
+{{ 1048575|filesizeformat:"binary" }}
@@ -4,4 +4,8 @@
 {% firstof doesnotexist simple.uint 42 %}
 {% firstof doesnotexist "test" simple.number 42 %}
 {% firstof %}
-{% firstof "test" "test2" %}
\ No newline at end of file
+{% firstof "test" "test2" %}
+{% firstof simple.nil simple.bool_false 0 0.0 "" simple.str %}
+{% firstof simple.nil simple.bool_false 0 "" "fallback" %}
+[{% firstof simple.nil simple.bool_false 0 "" %}]
+{% firstof simple.nil simple.xss "fallback" %}
\ No newline at end of file
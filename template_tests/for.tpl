@@ -42,4 +42,11 @@ string unicode
 '{% for char in simple.chinese_hello_world %}{{ char }}{% endfor %}'
 
 string unicode sorted reversed
-'{% for char in simple.chinese_hello_world reversed sorted %}{{ char }}{% endfor %}'
\ No newline at end of file
+'{% for char in simple.chinese_hello_world reversed sorted %}{{ char }}{% endfor %}'
+
+empty
+'{% for item in simple.multiple_item_list|slice:":0" %}{{ item }}{% empty %}empty slice{% endfor %}'
+'{% for item in simple.nil %}{{ item }}{% empty %}nil{% endfor %}'
+'{% for item in simple.one_item_list %}{{ item }}{% empty %}not rendered{% endfor %}'
+'{% for item in simple.nil %}{{ item }}{% empty %}forloop: {{ forloop.Counter|default:"undefined" }}{% endfor %}'
+'{% for i in simple.one_item_list %}{% for item in simple.nil %}{% empty %}parent: {{ forloop.Counter }}{% endfor %}{% endfor %}'
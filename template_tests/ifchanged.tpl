@@ -6,4 +6,7 @@
         Validated value not changed
     {% endifchanged %}
     {% ifchanged comment.Author.Name comment.Date %}Comment's author name or date changed{% endifchanged %}
-{% endfor %}
\ No newline at end of file
+{% endfor %}
+{% for c in "aabccc" %}{% ifchanged c %}[{{ c }}]{% else %}.{% endifchanged %}{% endfor %}
+{% for c in "aabccc" %}{% ifchanged %}{{ c }}{% else %}-{% endifchanged %}{% endfor %}
+{% for row in "xy" %}{% for c in "aab" %}{% ifchanged c %}{{ c }}{% else %}-{% endifchanged %}{% endfor %}|{% endfor %}
@@ -0,0 +1,5 @@
+{% include_raw "include_raw.helper" %}
+{% include_raw "include_raw.helper" escaped %}
+{% include_raw "ssi.helper" %}
+{% with name="include_raw.helper" %}{% include_raw name escaped %}{% endwith %}
+'{% include_raw "doesnotexist.helper" ignore missing %}' '{% include_raw "doesnotexist.helper" escaped if_exists %}' '{% include_raw simple.included_file_not_exists ignore missing escaped %}'
@@ -4,4 +4,11 @@ Start '{% include "includes.helper" with what_am_i=simple.name only %}' End
 Start '{% include "includes.helper" with what_am_i=simple.name %}' End
 Start '{% include simple.included_file|lower with number=7 what_am_i="guest" %}' End
 Start '{% include "includes.helper.not_exists" if_exists %}' End
-Start '{% include simple.included_file_not_exists if_exists with number=7 what_am_i="guest" %}' End
\ No newline at end of file
+Start '{% include simple.included_file_not_exists if_exists with number=7 what_am_i="guest" %}' End
+Start '{% include "includes.helper" only %}' End
+Start '{% include simple.included_file|lower only %}' End
+Start '{% include "includes.helper" with number=simple.number only %}' End
+{% with what_am_i="parent" number=1 %}Start '{% include "includes.helper" %}' '{% include "includes.helper" only %}' '{% include "includes.helper" with number=2 %}' '{% include "includes.helper" with number=2 only %}' End{% endwith %}
+Start '{% include "includes.helper.not_exists" ignore missing %}' End
+Start '{% include simple.included_file_not_exists ignore missing %}' End
+Start '{% include "includes.helper" ignore missing with what_am_i="present" only %}' End
@@ -0,0 +1,6 @@
+{% regroup complex.comments by Date as grouped %}{{ grouped|length }}
+{% for group in grouped %}{{ group.grouper|date:"2006" }}: {% for c in group.list %}{{ c.Author.Name }} {% endfor %}| {% endfor %}
+{% regroup complex.comments2 by Author.Name as byauthor %}{% for group in byauthor %}{{ group.grouper }}={{ group.list|length }} {% endfor %}
+{% regroup complex.comments by Missing as missing %}{% for group in missing %}{{ group.grouper|default:"none" }}: {{ group.list|length }}{% endfor %}
+{% regroup "aabac" by 0 as letters %}{% for group in letters %}{{ group.list|join:"-" }},{% endfor %}
+{% regroup simple.nil by Date as empty %}{% for group in empty %}x{% empty %}empty{% endfor %}
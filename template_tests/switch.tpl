@@ -0,0 +1,12 @@
+{% switch simple.name %}
+  {% case "jane doe" %}jane
+  {% case "john doe" %}john
+  {% default %}nobody
+{% endswitch %}
+{% switch simple.number %}{% case 1 %}one{% case 40|add:2 %}forty-two{% endswitch %}
+{% switch simple.number %}{% case 1 %}one{% case 2 %}two{% default %}default{% endswitch %}
+{% switch simple.number %}{% case 1 %}one{% case 2 %}two{% endswitch %}
+{% switch simple.str %}{% case "a", "b" %}a or b{% case "string", "text" %}string or text{% endswitch %}
+{% switch simple.uint %}{% case 8 %}uint equals int{% endswitch %}
+{% switch simple.nil %}{% case "" %}empty{% default %}nil{% endswitch %}
+{% for i in simple.multiple_item_list %}{% switch i %}{% case 1 %}{% switch forloop.Counter %}{% case 1 %}first one {% default %}second one {% endswitch %}{% case 2, 3 %}two or three {% default %}{{ i }} {% endswitch %}{% endfor %}
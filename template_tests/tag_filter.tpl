@@ -1,3 +1,8 @@
 {% filter lower %}This is a nice test; let's see whether it works. Foobar. {{ simple.xss }}{% endfilter %}
 
-{% filter truncatechars:10|lower|length %}This is a nice test; let's see whether it works. Foobar. {{ simple.number }}{% endfilter %}
\ No newline at end of file
+{% filter truncatechars:10|lower|length %}This is a nice test; let's see whether it works. Foobar. {{ simple.number }}{% endfilter %}
+{% filter upper|truncatechars:20 as heading %}This is a nice test for {{ simple.name }}.{% endfilter %}[{{ heading }}] [{{ heading|lower }}]
+{% filter lower as quoted %}<B>{{ simple.xss }}</B>{% endfilter %}{{ quoted }}
+{% filter length as count %}{% for i in simple.multiple_item_list %}{{ i }}{% endfor %}{% endfilter %}{{ count }} {{ count|add:1 }}
+
+{% filter apply:"upper" %}applied {{ simple.name }}{% endfilter %}
\ No newline at end of file
@@ -20,4 +20,11 @@
 {{ complex.comments.0["Tex" + "t"]|safe }}
 {{ complex.comments.0[0] }}
 {{ simple.stringer }}
-{{ simple.stringerPtr }}
\ No newline at end of file
+{{ simple.stringerPtr }}
+{{ simple.multiple_item_list[-1] }} {{ simple.multiple_item_list[-10] }} {{ simple.multiple_item_list[-11] }}
+{{ simple.multiple_item_list[1:3]|join:"," }} {{ simple.multiple_item_list[:2]|join:"," }} {{ simple.multiple_item_list[8:]|join:"," }} {{ simple.multiple_item_list[:]|join:"," }}
+{{ simple.multiple_item_list[-3:]|join:"," }} {{ simple.multiple_item_list[5:100]|join:"," }} {{ simple.multiple_item_list[-100:1]|join:"," }} {{ simple.multiple_item_list[6:2]|join:"," }}
+{{ simple.name[0] }} {{ simple.name[-1] }} {{ simple.name[1:4]|upper }} {{ simple.misc_list[0][1:3] }} {{ simple.misc_list.0[-2] }} {{ simple.name[20:] }}|
+{{ simple.misc_list[simple.uint-7:simple.uint-5]|join:"," }} {{ simple.misc_list[1:3]|length }}
+{{ simple.strmap["abc"] }} {{ simple.strmap["missing"] }}
+{% for i in simple.multiple_item_list[2:5] %}{{ i }},{% endfor %}
@@ -4,4 +4,8 @@
 {% test %}
 {% endverbatim %}{{ simple.number }}.
 
-.{{ simple.number }}{% verbatim %}{{ test }}{% endverbatim %}{{ simple.number }}.
\ No newline at end of file
+.{{ simple.number }}{% verbatim %}{{ test }}{% endverbatim %}{{ simple.number }}.
+{% verbatim vue %}<div>{{ mustache }}</div>{% verbatim %}{% endverbatim %}{% endverbatim vue %}{{ simple.number }}
+{% verbatim a_1 %}{% if x %}{{ y }}{% endif %}{% endverbatim a_1 %}
+
+{% verbatim %}{% endverbatim %}.
\ No newline at end of file
@@ -31,3 +31,10 @@ Trim everything:
 {% for i in simple.multiple_item_list -%}
 {{ i }}
 {%- endfor %}
+
+
+Variables:
+a   {{- simple.number -}}   b
+a   {{ simple.number -}}
+    b
+[	{{- " x " -}}	]
\ No newline at end of file
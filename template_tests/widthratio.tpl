@@ -1,4 +1,7 @@
 {# Tip: In pongo2 you can easily use arithmetic expressions like value/100.0, but widthratio is supported as well #}
 {% widthratio 175 200 100 %}
 {% widthratio 175 200 100 as width %}
-{{ width }}
\ No newline at end of file
+{{ width }}
+{% widthratio 50 100 100 %} {% widthratio 1 3 100 %} {% widthratio 2 3 100 %} {% widthratio 1 8 100 %} {% widthratio 3 8 10 %}
+{% widthratio 0 0 100 %} {% widthratio 5 0 100 %} {% widthratio simple.number simple.nil 100 %}
+{% widthratio simple.number 100 50 as half %}{{ half }} {% widthratio 1 0 100 as none %}{{ none }}
@@ -13,4 +13,8 @@ Start '{% include "with.helper" with what_am_i=simple.name number=10 %}' End
 more with tests
 {% with first_comment=complex.comments|first %}{{ first_comment.Author }}{% endwith %}
 {% with first_comment=complex.comments|first %}{{ first_comment.Author.Name }}{% endwith %}
-{% with first_comment=complex.comments|last %}{{ first_comment.Author.Name }}{% endwith %}
\ No newline at end of file
+{% with first_comment=complex.comments|last %}{{ first_comment.Author.Name }}{% endwith %}
+multiple assignments
+{% with name=simple.name upper_name=name|upper length=upper_name|length %}{{ name }} {{ upper_name }} {{ length }}{% endwith %}
+{% with number=1 %}{{ number }}{% with number=number|add:1 other=number|add:10 %} {{ number }} {{ other }}{% endwith %} {{ number }}{% endwith %} '{{ number }}'
+{% with simple.name as name name|length as length %}{{ name }} {{ length }}{% endwith %} '{{ name }}{{ length }}'
@@ -0,0 +1,93 @@
+package pongo2
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+)
+
+// templateTokensVersion is incremented whenever the serialized format changes.
+const templateTokensVersion = 1
+
+type templateTokens struct {
+	Version     int
+	Name        string
+	IsTplString bool
+	Size        int
+	Tokens      []Token
+}
+
+// MarshalTokens serializes the template's token stream (the lexed source), so
+// it can be cached externally and loaded using TemplateSet.FromTokens without
+// lexing the template's source again. The node tree is not serialized; the
+// tokens are parsed again when loading.
+//
+// Templates referenced by extends-, include- or import-tags are not part of
+// the serialized data, they're loaded from the set's loaders as usual.
+func (tpl *Template) MarshalTokens() ([]byte, error) {
+	bin := templateTokens{
+		Version:     templateTokensVersion,
+		Name:        tpl.name,
+		IsTplString: tpl.isTplString,
+		Size:        tpl.size,
+		Tokens:      make([]Token, 0, len(tpl.tokens)),
+	}
+	for _, t := range tpl.tokens {
+		bin.Tokens = append(bin.Tokens, *t)
+	}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(&bin); err != nil {
+		return nil, &Error{
+			Filename:  tpl.name,
+			Sender:    "marshaltokens",
+			OrigError: err,
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// FromTokens parses a template serialized by Template.MarshalTokens. Like
+// FromString, it fails if the template uses any tag or filter which is not
+// registered (or banned within this set).
+func (set *TemplateSet) FromTokens(data []byte) (*Template, error) {
+	set.firstTemplateCreated = true
+
+	var bin templateTokens
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&bin); err != nil {
+		return nil, &Error{
+			Sender:    "fromtokens",
+			OrigError: err,
+		}
+	}
+	if bin.Version != templateTokensVersion {
+		return nil, &Error{
+			Filename:  bin.Name,
+			Sender:    "fromtokens",
+			OrigError: fmt.Errorf("unsupported tokens format version %d (expected %d)", bin.Version, templateTokensVersion),
+		}
+	}
+
+	t := &Template{
+		set:            set,
+		isTplString:    bin.IsTplString,
+		name:           bin.Name,
+		size:           bin.Size,
+		tokens:         make([]*Token, 0, len(bin.Tokens)),
+		blocks:         make(map[string]*NodeWrapper),
+		exportedMacros: make(map[string]*tagMacroNode),
+		Options:        newOptions(),
+	}
+	t.Options.Update(set.Options)
+	for idx := range bin.Tokens {
+		t.tokens = append(t.tokens, &bin.Tokens[idx])
+	}
+
+	if err := t.parse(); err != nil {
+		return nil, err
+	}
+	if len(t.parseErrors) > 0 {
+		return nil, t.parseErrors
+	}
+	return t, nil
+}
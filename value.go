@@ -1,7 +1,9 @@
 package pongo2
 
 import (
+	"errors"
 	"fmt"
+	"math/big"
 	"reflect"
 	"sort"
 	"strconv"
@@ -52,14 +54,20 @@ func (v *Value) IsBool() bool {
 	return v.getResolvedValue().Kind() == reflect.Bool
 }
 
-// IsFloat checks whether the underlying value is a float
+// IsFloat checks whether the underlying value is a float (including *big.Float)
 func (v *Value) IsFloat() bool {
+	if _, ok := v.bigFloat(); ok {
+		return true
+	}
 	return v.getResolvedValue().Kind() == reflect.Float32 ||
 		v.getResolvedValue().Kind() == reflect.Float64
 }
 
-// IsInteger checks whether the underlying value is an integer
+// IsInteger checks whether the underlying value is an integer (including *big.Int)
 func (v *Value) IsInteger() bool {
+	if _, ok := v.bigInt(); ok {
+		return true
+	}
 	return v.getResolvedValue().Kind() == reflect.Int ||
 		v.getResolvedValue().Kind() == reflect.Int8 ||
 		v.getResolvedValue().Kind() == reflect.Int16 ||
@@ -78,6 +86,69 @@ func (v *Value) IsNumber() bool {
 	return v.IsInteger() || v.IsFloat()
 }
 
+// IsBigNumber checks whether the underlying value is a *big.Int or a *big.Float.
+func (v *Value) IsBigNumber() bool {
+	_, isInt := v.bigInt()
+	_, isFloat := v.bigFloat()
+	return isInt || isFloat
+}
+
+var (
+	bigIntType   = reflect.TypeOf((*big.Int)(nil))
+	bigFloatType = reflect.TypeOf((*big.Float)(nil))
+)
+
+// bigInt returns the underlying *big.Int (if it's one). The type is checked
+// before calling Interface() since that allocates for most other values and
+// these checks run for every number.
+func (v *Value) bigInt() (*big.Int, bool) {
+	if !v.val.IsValid() || v.val.Kind() != reflect.Ptr || v.val.Type() != bigIntType || v.val.IsNil() {
+		return nil, false
+	}
+	return v.val.Interface().(*big.Int), true
+}
+
+// bigFloat returns the underlying *big.Float (if it's one), see bigInt.
+func (v *Value) bigFloat() (*big.Float, bool) {
+	if !v.val.IsValid() || v.val.Kind() != reflect.Ptr || v.val.Type() != bigFloatType || v.val.IsNil() {
+		return nil, false
+	}
+	return v.val.Interface().(*big.Float), true
+}
+
+// BigInt returns the underlying number as a *big.Int (floats are truncated).
+// If the underlying value is not a number, it will return 0.
+func (v *Value) BigInt() *big.Int {
+	if i, ok := v.bigInt(); ok {
+		return i
+	}
+	if f, ok := v.bigFloat(); ok {
+		i, _ := f.Int(nil)
+		return i
+	}
+	switch v.getResolvedValue().Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return new(big.Int).SetUint64(v.getResolvedValue().Uint())
+	case reflect.Float32, reflect.Float64:
+		i, _ := big.NewFloat(v.getResolvedValue().Float()).Int(nil)
+		return i
+	default:
+		return big.NewInt(int64(v.Integer()))
+	}
+}
+
+// BigFloat returns the underlying number as a *big.Float. If the underlying
+// value is not a number, it will return 0.0.
+func (v *Value) BigFloat() *big.Float {
+	if f, ok := v.bigFloat(); ok {
+		return f
+	}
+	if v.IsInteger() {
+		return new(big.Float).SetInt(v.BigInt())
+	}
+	return big.NewFloat(v.Float())
+}
+
 // IsTime checks whether the underlying value is a time.Time.
 func (v *Value) IsTime() bool {
 	_, ok := v.Interface().(time.Time)
@@ -99,7 +170,8 @@ func (v *Value) IsNil() bool {
 //  3. float (any precision)
 //  4. bool
 //  5. time.Time
-//  6. String() will be called on the underlying value if provided
+//  6. *big.Int and *big.Float (in full precision)
+//  7. String() will be called on the underlying value if provided
 //
 // NIL values will lead to an empty string. Unsupported types are leading
 // to their respective type name.
@@ -108,6 +180,10 @@ func (v *Value) String() string {
 		return ""
 	}
 
+	if f, ok := v.bigFloat(); ok {
+		return f.Text('f', -1)
+	}
+
 	if t, ok := v.Interface().(fmt.Stringer); ok {
 		return t.String()
 	}
@@ -134,8 +210,16 @@ func (v *Value) String() string {
 
 // Integer returns the underlying value as an integer (converts the underlying
 // value, if necessary). If it's not possible to convert the underlying value,
-// it will return 0.
+// it will return 0. Big numbers out of the int range are truncated; use
+// BigInt() instead.
 func (v *Value) Integer() int {
+	if i, ok := v.bigInt(); ok {
+		return int(i.Int64())
+	}
+	if f, ok := v.bigFloat(); ok {
+		i, _ := f.Int64()
+		return int(i)
+	}
 	switch v.getResolvedValue().Kind() {
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
 		return int(v.getResolvedValue().Int())
@@ -158,8 +242,16 @@ func (v *Value) Integer() int {
 
 // Float returns the underlying value as a float (converts the underlying
 // value, if necessary). If it's not possible to convert the underlying value,
-// it will return 0.0.
+// it will return 0.0. Big numbers lose precision; use BigFloat() instead.
 func (v *Value) Float() float64 {
+	if i, ok := v.bigInt(); ok {
+		f, _ := new(big.Float).SetInt(i).Float64()
+		return f
+	}
+	if f, ok := v.bigFloat(); ok {
+		f64, _ := f.Float64()
+		return f64
+	}
 	switch v.getResolvedValue().Kind() {
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
 		return float64(v.getResolvedValue().Int())
@@ -216,6 +308,9 @@ func (v *Value) Time() time.Time {
 //
 // Otherwise returns always FALSE.
 func (v *Value) IsTrue() bool {
+	if v.IsBigNumber() {
+		return v.BigFloat().Sign() != 0
+	}
 	switch v.getResolvedValue().Kind() {
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
 		return v.getResolvedValue().Int() != 0
@@ -243,6 +338,9 @@ func (v *Value) IsTrue() bool {
 //
 //	AsValue(1).Negate().IsTrue() == false
 func (v *Value) Negate() *Value {
+	if v.IsBigNumber() {
+		return AsValue(v.BigFloat().Sign() == 0)
+	}
 	switch v.getResolvedValue().Kind() {
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
 		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
@@ -372,6 +470,28 @@ func (v *Value) Contains(other *Value) bool {
 	}
 }
 
+// getAttribute resolves an attribute (a struct field, method or map key) of the
+// value the same way a template does for `value.attribute`. Nested attributes are
+// separated by dots (e. g. "Author.Name"). Missing attributes lead to a nil value.
+func (v *Value) getAttribute(name string) *Value {
+	resolver := &variableResolver{
+		parts: []*variablePart{{typ: varTypeIdent, s: "value"}},
+	}
+	for _, part := range strings.Split(name, ".") {
+		if i, err := strconv.Atoi(part); err == nil {
+			resolver.parts = append(resolver.parts, &variablePart{typ: varTypeInt, i: i})
+		} else {
+			resolver.parts = append(resolver.parts, &variablePart{typ: varTypeIdent, s: part})
+		}
+	}
+
+	val, err := resolver.resolve(&ExecutionContext{Private: Context{"value": v}})
+	if err != nil {
+		return AsValue(nil)
+	}
+	return val
+}
+
 // CanSlice checks whether the underlying value is of type array, slice or string.
 // You normally would use CanSlice() before using the Slice() operation.
 func (v *Value) CanSlice() bool {
@@ -382,8 +502,9 @@ func (v *Value) CanSlice() bool {
 	return false
 }
 
-// Iterate iterates over a map, array, slice or a string. It calls the
-// function's first argument for every value with the following arguments:
+// Iterate iterates over a map, array, slice, channel (which must be closed,
+// see IterateOrder) or a string. It calls the function's first argument for
+// every value with the following arguments:
 //
 //	idx      current 0-index
 //	count    total amount of items
@@ -399,7 +520,47 @@ func (v *Value) Iterate(fn func(idx, count int, key, value *Value) bool, empty f
 // IterateOrder behaves like Value.Iterate, but can iterate through an array/slice/string in reverse. Does
 // not affect the iteration through a map because maps don't have any particular order.
 // However, you can force an order using the `sorted` keyword (and even use `reversed sorted`).
+//
+// A channel is received from until it's closed, so make sure it gets closed
+// (an unclosed channel blocks forever). A nil channel is empty; a send-only
+// channel can't be iterated, for it the empty function is called.
 func (v *Value) IterateOrder(fn func(idx, count int, key, value *Value) bool, empty func(), reverse bool, sorted bool) {
+	if err := v.iterateOrder(nil, nil, fn, empty, reverse, sorted); err != nil {
+		empty()
+	}
+}
+
+// receiveAll receives the items of a channel until it's closed. Receiving
+// is aborted with an error once done (if not nil) is closed.
+func receiveAll(ch reflect.Value, done <-chan struct{}, doneErr func() error) (valuesList, error) {
+	if ch.IsNil() {
+		return nil, nil
+	}
+	if ch.Type().ChanDir()&reflect.RecvDir == 0 {
+		return nil, fmt.Errorf("cannot iterate over a send-only channel (%s)", ch.Type())
+	}
+
+	cases := []reflect.SelectCase{{Dir: reflect.SelectRecv, Chan: ch}}
+	if done != nil {
+		cases = append(cases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(done)})
+	}
+
+	var items valuesList
+	for {
+		chosen, item, ok := reflect.Select(cases)
+		if chosen == 1 {
+			return nil, doneErr()
+		}
+		if !ok {
+			return items, nil
+		}
+		items = append(items, &Value{val: item})
+	}
+}
+
+// iterateOrder implements IterateOrder. done (may be nil) aborts receiving
+// from a channel, the error returned then is doneErr's.
+func (v *Value) iterateOrder(done <-chan struct{}, doneErr func() error, fn func(idx, count int, key, value *Value) bool, empty func(), reverse bool, sorted bool) error {
 	switch v.getResolvedValue().Kind() {
 	case reflect.Map:
 		keys := sortedKeys(v.getResolvedValue().MapKeys())
@@ -414,20 +575,28 @@ func (v *Value) IterateOrder(fn func(idx, count int, key, value *Value) bool, em
 		for idx, key := range keys {
 			value := v.getResolvedValue().MapIndex(key)
 			if !fn(idx, keyLen, &Value{val: key}, &Value{val: value}) {
-				return
+				return nil
 			}
 		}
 		if keyLen == 0 {
 			empty()
 		}
-		return // done
-	case reflect.Array, reflect.Slice:
+		return nil // done
+	case reflect.Array, reflect.Slice, reflect.Chan:
 		var items valuesList
 
-		itemCount := v.getResolvedValue().Len()
-		for i := 0; i < itemCount; i++ {
-			items = append(items, &Value{val: v.getResolvedValue().Index(i)})
+		if v.getResolvedValue().Kind() == reflect.Chan {
+			var err error
+			items, err = receiveAll(v.getResolvedValue(), done, doneErr)
+			if err != nil {
+				return err
+			}
+		} else {
+			for i := 0; i < v.getResolvedValue().Len(); i++ {
+				items = append(items, &Value{val: v.getResolvedValue().Index(i)})
+			}
 		}
+		itemCount := len(items)
 
 		if sorted {
 			if reverse {
@@ -446,13 +615,13 @@ func (v *Value) IterateOrder(fn func(idx, count int, key, value *Value) bool, em
 		if len(items) > 0 {
 			for idx, item := range items {
 				if !fn(idx, itemCount, item, nil) {
-					return
+					return nil
 				}
 			}
 		} else {
 			empty()
 		}
-		return // done
+		return nil // done
 	case reflect.String:
 		s := v.getResolvedValue().String()
 		rs := []rune(s)
@@ -473,17 +642,18 @@ func (v *Value) IterateOrder(fn func(idx, count int, key, value *Value) bool, em
 
 			for i := 0; i < charCount; i++ {
 				if !fn(i, charCount, &Value{val: reflect.ValueOf(string(rs[i]))}, nil) {
-					return
+					return nil
 				}
 			}
 		} else {
 			empty()
 		}
-		return // done
+		return nil // done
 	default:
 		logf("Value.Iterate() not available for type: %s\n", v.getResolvedValue().Kind().String())
 	}
 	empty()
+	return nil
 }
 
 // Interface gives you access to the underlying value.
@@ -496,6 +666,9 @@ func (v *Value) Interface() any {
 
 // EqualValueTo checks whether two values are containing the same value or object (if comparable).
 func (v *Value) EqualValueTo(other *Value) bool {
+	if isBigArithmetic(v, other) {
+		return bigCompare(v, other) == 0
+	}
 	// comparison of uint with int fails using .Interface()-comparison (see issue #64)
 	if v.IsInteger() && other.IsInteger() {
 		return v.Integer() == other.Integer()
@@ -523,6 +696,8 @@ func (sk sortedKeys) Less(i, j int) bool {
 	vi := &Value{val: sk[i]}
 	vj := &Value{val: sk[j]}
 	switch {
+	case isBigArithmetic(vi, vj):
+		return bigCompare(vi, vj) < 0
 	case vi.IsInteger() && vj.IsInteger():
 		return vi.Integer() < vj.Integer()
 	case vi.IsFloat() && vj.IsFloat():
@@ -543,9 +718,13 @@ func (vl valuesList) Len() int {
 }
 
 func (vl valuesList) Less(i, j int) bool {
-	vi := vl[i]
-	vj := vl[j]
+	return valueLess(vl[i], vl[j])
+}
+
+func valueLess(vi, vj *Value) bool {
 	switch {
+	case isBigArithmetic(vi, vj):
+		return bigCompare(vi, vj) < 0
 	case vi.IsInteger() && vj.IsInteger():
 		return vi.Integer() < vj.Integer()
 	case vi.IsFloat() && vj.IsFloat():
@@ -558,3 +737,67 @@ func (vl valuesList) Less(i, j int) bool {
 func (vl valuesList) Swap(i, j int) {
 	vl[i], vl[j] = vl[j], vl[i]
 }
+
+// isBigArithmetic returns true if both values are numbers and at least one of
+// them is a big number, so the operation must be done in big arithmetic to
+// not lose any precision.
+func isBigArithmetic(a, b *Value) bool {
+	return (a.IsBigNumber() || b.IsBigNumber()) && a.IsNumber() && b.IsNumber()
+}
+
+// bigCompare compares two numbers in big arithmetic (see big.Float.Cmp).
+func bigCompare(a, b *Value) int {
+	if a.IsFloat() || b.IsFloat() {
+		return a.BigFloat().Cmp(b.BigFloat())
+	}
+	return a.BigInt().Cmp(b.BigInt())
+}
+
+// bigArithmetic applies op (+, -, *, / or %) to two numbers in big arithmetic.
+// The result is a *big.Float if one of them is a float, otherwise a *big.Int
+// (using truncated division).
+func bigArithmetic(op string, a, b *Value) (*Value, error) {
+	if (a.IsFloat() || b.IsFloat()) && op != "%" {
+		x, y := a.BigFloat(), b.BigFloat()
+		z := new(big.Float)
+		switch op {
+		case "+":
+			z.Add(x, y)
+		case "-":
+			z.Sub(x, y)
+		case "*":
+			z.Mul(x, y)
+		case "/":
+			if y.Sign() == 0 {
+				return nil, errors.New("float divide by zero")
+			}
+			z.Quo(x, y)
+		default:
+			return nil, fmt.Errorf("unimplemented: %s", op)
+		}
+		return AsValue(z), nil
+	}
+
+	x, y := a.BigInt(), b.BigInt()
+	z := new(big.Int)
+	switch op {
+	case "+":
+		z.Add(x, y)
+	case "-":
+		z.Sub(x, y)
+	case "*":
+		z.Mul(x, y)
+	case "/", "%":
+		if y.Sign() == 0 {
+			return nil, errors.New("integer divide by zero")
+		}
+		if op == "/" {
+			z.Quo(x, y)
+		} else {
+			z.Rem(x, y)
+		}
+	default:
+		return nil, fmt.Errorf("unimplemented: %s", op)
+	}
+	return AsValue(z), nil
+}
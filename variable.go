@@ -28,8 +28,14 @@ type variablePart struct {
 	subscript IEvaluator
 	isNil     bool
 
+	// [from:to] subscripts; a nil bound is open (start or end respectively)
+	isSlice   bool
+	sliceFrom IEvaluator
+	sliceTo   IEvaluator
+
 	isFunctionCall bool
 	callingArgs    []functionCallArgument // needed for a function call, represents all argument nodes (INode supports nested function calls)
+	callingKwargs  []functionCallKwarg    // keyword arguments of a function call (in order of appearance)
 }
 
 func (p *variablePart) String() string {
@@ -39,6 +45,9 @@ func (p *variablePart) String() string {
 	case varTypeIdent:
 		return p.s
 	case varTypeSubscript:
+		if p.isSlice {
+			return "[slice]"
+		}
 		return "[subscript]"
 	case varTypeArray:
 		return "[array]"
@@ -51,6 +60,12 @@ type functionCallArgument interface {
 	Evaluate(*ExecutionContext) (*Value, *Error)
 }
 
+// functionCallKwarg is a keyword argument of a function call: name=value
+type functionCallKwarg struct {
+	name  string
+	value functionCallArgument
+}
+
 // TODO: Add location tokens
 type stringResolver struct {
 	locationToken *Token
@@ -247,6 +262,86 @@ func (vr *variableResolver) String() string {
 	return strings.Join(parts, ".")
 }
 
+// indexSubscript returns the i-th item of a slice, array or string (the i-th
+// rune of a string); negative indices count from the end.
+func indexSubscript(current reflect.Value, i int) (reflect.Value, bool) {
+	if current.Kind() == reflect.String {
+		runes := []rune(current.String())
+		if i < 0 {
+			i += len(runes)
+		}
+		if i < 0 || i >= len(runes) {
+			return reflect.Value{}, false
+		}
+		return reflect.ValueOf(string(runes[i])), true
+	}
+
+	if i < 0 {
+		i += current.Len()
+	}
+	if i < 0 || i >= current.Len() {
+		return reflect.Value{}, false
+	}
+	return current.Index(i), true
+}
+
+// sliceSubscript resolves [from:to] of a slice, array or string (in runes).
+// Negative bounds count from the end and bounds out of range are clamped.
+func (vr *variableResolver) sliceSubscript(ctx *ExecutionContext, part *variablePart, current reflect.Value) (reflect.Value, error) {
+	var runes []rune
+	length := current.Len()
+	if current.Kind() == reflect.String {
+		runes = []rune(current.String())
+		length = len(runes)
+	}
+
+	bound := func(expr IEvaluator, def int) (int, error) {
+		if expr == nil {
+			return def, nil
+		}
+		v, err := expr.Evaluate(ctx)
+		if err != nil {
+			return 0, err
+		}
+		i := v.Integer()
+		if i < 0 {
+			i += length
+		}
+		if i < 0 {
+			i = 0
+		}
+		if i > length {
+			i = length
+		}
+		return i, nil
+	}
+
+	from, err := bound(part.sliceFrom, 0)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	to, err := bound(part.sliceTo, length)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	if to < from {
+		to = from
+	}
+
+	switch current.Kind() {
+	case reflect.String:
+		return reflect.ValueOf(string(runes[from:to])), nil
+	case reflect.Array:
+		if !current.CanAddr() {
+			// Only addressable arrays can be sliced
+			arr := reflect.New(current.Type()).Elem()
+			arr.Set(current)
+			current = arr
+		}
+	}
+	return current.Slice(from, to), nil
+}
+
 func (vr *variableResolver) resolve(ctx *ExecutionContext) (*Value, error) {
 	var current reflect.Value
 	var isSafe bool
@@ -282,12 +377,26 @@ func (vr *variableResolver) resolve(ctx *ExecutionContext) (*Value, error) {
 			val, inPrivate := ctx.Private[vr.parts[0].s]
 			if !inPrivate {
 				// Nothing found? Then have a final lookup in the public context
-				val = ctx.Public[vr.parts[0].s]
+				var inPublic bool
+				val, inPublic = ctx.Public[vr.parts[0].s]
+				if !inPublic && ctx.template != nil {
+					// Undefined
+					undefined, err := ctx.template.set.resolveUndefined(vr.parts[0].s)
+					if err != nil {
+						return nil, err
+					}
+					val = undefined
+				}
 			}
 			current = reflect.ValueOf(val) // Get the initial value
 		} else {
 			// Next parts, resolve it from current
 
+			// In nil-safe mode nothing can be resolved from a nil value
+			if ctx.template != nil && ctx.template.set.NilSafeAttributes && isNilReflectValue(current) {
+				return AsValue(nil), nil
+			}
+
 			// Before resolving the pointer, let's see if we have a method to call
 			// Problem with resolving the pointer is we're changing the receiver
 			isFunc := false
@@ -338,6 +447,23 @@ func (vr *variableResolver) resolve(ctx *ExecutionContext) (*Value, error) {
 							current.Kind().String(), vr.String())
 					}
 				case varTypeSubscript:
+					if part.isSlice {
+						// Slicing is only possible for:
+						// * slices/arrays/strings
+						switch current.Kind() {
+						case reflect.String, reflect.Array, reflect.Slice:
+							var err error
+							current, err = vr.sliceSubscript(ctx, part, current)
+							if err != nil {
+								return nil, err
+							}
+						default:
+							return nil, fmt.Errorf("can't slice type %s (variable %s)",
+								current.Kind().String(), vr.String())
+						}
+						break
+					}
+
 					// Calling an index is only possible for:
 					// * slices/arrays/strings
 					switch current.Kind() {
@@ -346,10 +472,9 @@ func (vr *variableResolver) resolve(ctx *ExecutionContext) (*Value, error) {
 						if err != nil {
 							return nil, err
 						}
-						si := sv.Integer()
-						if si >= 0 && current.Len() > si {
-							current = current.Index(si)
-						} else {
+						var ok bool
+						current, ok = indexSubscript(current, sv.Integer())
+						if !ok {
 							// In Django, exceeding the length of a list is just empty.
 							return AsValue(nil), nil
 						}
@@ -402,6 +527,25 @@ func (vr *variableResolver) resolve(ctx *ExecutionContext) (*Value, error) {
 			current = reflect.ValueOf(current.Interface())
 		}
 
+		// Macros are called directly since they take keyword arguments
+		if current.Kind() == reflect.Func && current.CanInterface() {
+			if macro, ok := current.Interface().(macroFunction); ok {
+				rv, err := vr.callMacro(ctx, part, macro)
+				if err != nil {
+					return nil, err
+				}
+				current = rv.val
+				isSafe = rv.safe
+				if !current.IsValid() {
+					return AsValue(nil), nil
+				}
+				continue
+			}
+		}
+		if len(part.callingKwargs) > 0 && current.Kind() != reflect.Func {
+			return nil, fmt.Errorf("'%s' does not take keyword arguments", vr.String())
+		}
+
 		// Check if the part is a function call
 		if part.isFunctionCall || current.Kind() == reflect.Func {
 			// Check for callable
@@ -419,11 +563,23 @@ func (vr *variableResolver) resolve(ctx *ExecutionContext) (*Value, error) {
 				currArgs = append([]functionCallArgument{executionCtxEval{}}, currArgs...)
 			}
 
+			// Keyword arguments are collected into a trailing options parameter,
+			// which is also filled (with its zero value) if it's left out
+			var optionsType reflect.Type
+			numIn := t.NumIn()
+			if numIn > 0 && !t.IsVariadic() && isOptionsType(t.In(numIn-1)) &&
+				(len(part.callingKwargs) > 0 || len(currArgs) == numIn-1) {
+				optionsType = t.In(numIn - 1)
+				numIn--
+			} else if len(part.callingKwargs) > 0 {
+				return nil, fmt.Errorf("'%s' does not take keyword arguments", vr.String())
+			}
+
 			// Input arguments
-			if len(currArgs) != t.NumIn() && !(len(currArgs) >= t.NumIn()-1 && t.IsVariadic()) {
+			if len(currArgs) != numIn && !(len(currArgs) >= numIn-1 && t.IsVariadic()) {
 				return nil,
 					fmt.Errorf("function input argument count (%d) of '%s' must be equal to the calling argument count (%d)",
-						t.NumIn(), vr.String(), len(currArgs))
+						numIn, vr.String(), len(currArgs))
 			}
 
 			// Output arguments
@@ -455,23 +611,23 @@ func (vr *variableResolver) resolve(ctx *ExecutionContext) (*Value, error) {
 				}
 
 				if fnArg != typeOfValuePtr {
-					// Function's argument is not a *pongo2.Value, then we have to check whether input argument is of the same type as the function's argument
-					if !isVariadic {
-						if fnArg != reflect.TypeOf(pv.Interface()) && fnArg.Kind() != reflect.Interface {
-							return nil, fmt.Errorf("function input argument %d of '%s' must be of type %s or *pongo2.Value (not %T)",
-								idx, vr.String(), fnArg.String(), pv.Interface())
-						}
-					} else {
-						if fnArg != reflect.TypeOf(pv.Interface()) && fnArg.Kind() != reflect.Interface {
+					// Function's argument is not a *pongo2.Value, then we have to check whether input argument is assignable to the function's argument
+					var argType reflect.Type
+					if !pv.IsNil() {
+						argType = reflect.TypeOf(pv.Interface())
+					}
+					if (argType == nil && !canBeNil(fnArg)) || (argType != nil && !argType.AssignableTo(fnArg)) {
+						if isVariadic && idx >= numArgs-1 {
 							return nil, fmt.Errorf("function variadic input argument of '%s' must be of type %s or *pongo2.Value (not %T)",
 								vr.String(), fnArg.String(), pv.Interface())
 						}
+						return nil, fmt.Errorf("function input argument %d of '%s' must be of type %s or *pongo2.Value (not %T)",
+							idx, vr.String(), fnArg.String(), pv.Interface())
 					}
 
-					if pv.IsNil() {
-						// Workaround to present an interface nil as reflect.Value
-						var empty any = nil
-						parameters = append(parameters, reflect.ValueOf(&empty).Elem())
+					if argType == nil {
+						// A typed nil of the function argument's type
+						parameters = append(parameters, reflect.Zero(fnArg))
 					} else {
 						parameters = append(parameters, reflect.ValueOf(pv.Interface()))
 					}
@@ -481,6 +637,14 @@ func (vr *variableResolver) resolve(ctx *ExecutionContext) (*Value, error) {
 				}
 			}
 
+			if optionsType != nil {
+				options, err := vr.functionOptions(ctx, part.callingKwargs, optionsType)
+				if err != nil {
+					return nil, err
+				}
+				parameters = append(parameters, options)
+			}
+
 			// Check if any of the values are invalid
 			for _, p := range parameters {
 				if p.Kind() == reflect.Invalid {
@@ -522,9 +686,165 @@ func (vr *variableResolver) resolve(ctx *ExecutionContext) (*Value, error) {
 	return &Value{val: current, safe: isSafe}, nil
 }
 
+// isOptionsType returns true if t can take the keyword arguments of a function
+// call: a map[string]any (or map[string]*Value) or a struct (or a pointer to
+// a struct) with exported fields.
+func isOptionsType(t reflect.Type) bool {
+	if t.Kind() == reflect.Map {
+		return t.Key().Kind() == reflect.String &&
+			(t.Elem() == typeOfValuePtr || (t.Elem().Kind() == reflect.Interface && t.Elem().NumMethod() == 0))
+	}
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).PkgPath == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// optionsField returns the field of an options struct a keyword argument is
+// assigned to: the field tagged with `pongo2:"name"` or, if there's none, the
+// untagged field whose name equals the keyword (case-insensitive).
+func optionsField(t reflect.Type, name string) (reflect.StructField, bool) {
+	var match *reflect.StructField
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		tag, tagged := field.Tag.Lookup("pongo2")
+		if tagged {
+			if tag == name {
+				return field, true
+			}
+			continue
+		}
+		if match == nil && strings.EqualFold(field.Name, name) {
+			match = &field
+		}
+	}
+	if match == nil {
+		return reflect.StructField{}, false
+	}
+	return *match, true
+}
+
+// functionOptions builds the trailing options parameter of type t of a
+// function from the call's keyword arguments.
+func (vr *variableResolver) functionOptions(ctx *ExecutionContext, kwargs []functionCallKwarg, t reflect.Type) (reflect.Value, error) {
+	if t.Kind() == reflect.Map {
+		options := reflect.MakeMapWithSize(t, len(kwargs))
+		for _, kwarg := range kwargs {
+			pv, err := kwarg.value.Evaluate(ctx)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			value := reflect.ValueOf(pv)
+			if t.Elem() != typeOfValuePtr {
+				value = reflect.Zero(t.Elem())
+				if !pv.IsNil() {
+					value = reflect.ValueOf(pv.Interface())
+				}
+			}
+			options.SetMapIndex(reflect.ValueOf(kwarg.name).Convert(t.Key()), value)
+		}
+		return options, nil
+	}
+
+	structType := t
+	if t.Kind() == reflect.Ptr {
+		structType = t.Elem()
+	}
+	options := reflect.New(structType).Elem()
+	for _, kwarg := range kwargs {
+		field, found := optionsField(structType, kwarg.name)
+		if !found {
+			return reflect.Value{}, fmt.Errorf("'%s' has no keyword argument '%s'", vr.String(), kwarg.name)
+		}
+		pv, err := kwarg.value.Evaluate(ctx)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+
+		fieldValue := options.FieldByIndex(field.Index)
+		switch {
+		case field.Type == typeOfValuePtr:
+			fieldValue.Set(reflect.ValueOf(pv))
+		case pv.IsNil() && canBeNil(field.Type):
+			// Already the zero value
+		case !pv.IsNil() && reflect.TypeOf(pv.Interface()).AssignableTo(field.Type):
+			fieldValue.Set(reflect.ValueOf(pv.Interface()))
+		default:
+			return reflect.Value{}, fmt.Errorf("keyword argument '%s' of '%s' must be of type %s or *pongo2.Value (not %T)",
+				kwarg.name, vr.String(), field.Type.String(), pv.Interface())
+		}
+	}
+	if t.Kind() == reflect.Ptr {
+		return options.Addr(), nil
+	}
+	return options, nil
+}
+
+// canBeNil returns true if nil can be passed as a function argument of type t.
+func canBeNil(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Chan, reflect.Func, reflect.Interface, reflect.Map, reflect.Ptr, reflect.Slice:
+		return true
+	}
+	return false
+}
+
+func (vr *variableResolver) callMacro(ctx *ExecutionContext, part *variablePart, macro macroFunction) (*Value, error) {
+	args := make([]*Value, 0, len(part.callingArgs))
+	for _, arg := range part.callingArgs {
+		pv, err := arg.Evaluate(ctx)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, pv)
+	}
+
+	var kwargs map[string]*Value
+	if len(part.callingKwargs) > 0 {
+		kwargs = make(map[string]*Value, len(part.callingKwargs))
+		for _, kwarg := range part.callingKwargs {
+			pv, err := kwarg.value.Evaluate(ctx)
+			if err != nil {
+				return nil, err
+			}
+			kwargs[kwarg.name] = pv
+		}
+	}
+
+	return macro(args, kwargs)
+}
+
+// isNilReflectValue returns true if v is either invalid or a nil pointer,
+// interface, map, slice, func or chan.
+func isNilReflectValue(v reflect.Value) bool {
+	if !v.IsValid() {
+		return true
+	}
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan:
+		return v.IsNil()
+	default:
+		return false
+	}
+}
+
 func (vr *variableResolver) Evaluate(ctx *ExecutionContext) (*Value, *Error) {
 	value, err := vr.resolve(ctx)
 	if err != nil {
+		if perr, ok := err.(*Error); ok {
+			return AsValue(nil), perr.updateFromTokenIfNeeded(ctx.template, vr.locationToken)
+		}
 		return AsValue(nil), ctx.Error(err.Error(), vr.locationToken)
 	}
 	return value, nil
@@ -574,7 +894,9 @@ func (p *Parser) parseArray() (IEvaluator, *Error) {
 		}
 
 		// No closing bracket, so we're parsing an expression
+		p.argumentListDepth++
 		exprArg, err := p.ParseExpression()
+		p.argumentListDepth--
 		if err != nil {
 			return nil, err
 		}
@@ -734,18 +1056,39 @@ variableLoop:
 				return nil, p.Error("Unexpected EOF, expected subscript subscript.", p.lastToken)
 			}
 
-			exprSubscript, err := p.ParseExpression()
-			if err != nil {
-				return nil, err
+			part := &variablePart{
+				typ: varTypeSubscript,
+			}
+
+			var exprSubscript IEvaluator
+			if p.Peek(TokenSymbol, ":") == nil {
+				var err *Error
+				exprSubscript, err = p.ParseExpression()
+				if err != nil {
+					return nil, err
+				}
+			}
+
+			if p.Match(TokenSymbol, ":") != nil {
+				// Slice subscript [from:to]; both bounds are optional
+				part.isSlice = true
+				part.sliceFrom = exprSubscript
+				if p.Peek(TokenSymbol, "]") == nil {
+					exprTo, err := p.ParseExpression()
+					if err != nil {
+						return nil, err
+					}
+					part.sliceTo = exprTo
+				}
+			} else {
+				part.subscript = exprSubscript
 			}
-			resolver.parts = append(resolver.parts, &variablePart{
-				typ:       varTypeSubscript,
-				subscript: exprSubscript,
-			})
+			resolver.parts = append(resolver.parts, part)
+
 			if p.Match(TokenSymbol, "]") == nil {
 				return nil, p.Error("Missing closing bracket after subscript argument.", nil)
 			}
-
+			continue variableLoop
 		} else if p.Match(TokenSymbol, "(") != nil {
 			// Function call
 			// FunctionName '(' Comma-separated list of expressions ')'
@@ -759,11 +1102,31 @@ variableLoop:
 
 				if p.Peek(TokenSymbol, ")") == nil {
 					// No closing bracket, so we're parsing an expression
+					// (optionally preceded by a keyword: name=expression)
+					var kwargToken *Token
+					if p.PeekType(TokenIdentifier) != nil && p.PeekN(1, TokenSymbol, "=") != nil {
+						kwargToken = p.Current()
+						p.ConsumeN(2)
+						for _, kwarg := range part.callingKwargs {
+							if kwarg.name == kwargToken.Val {
+								return nil, p.Error(fmt.Sprintf("Keyword argument '%s' given twice.", kwargToken.Val), kwargToken)
+							}
+						}
+					} else if len(part.callingKwargs) > 0 {
+						return nil, p.Error("Positional arguments must not follow keyword arguments.", nil)
+					}
+
+					p.argumentListDepth++
 					exprArg, err := p.ParseExpression()
+					p.argumentListDepth--
 					if err != nil {
 						return nil, err
 					}
-					part.callingArgs = append(part.callingArgs, exprArg)
+					if kwargToken != nil {
+						part.callingKwargs = append(part.callingKwargs, functionCallKwarg{name: kwargToken.Val, value: exprArg})
+					} else {
+						part.callingArgs = append(part.callingArgs, exprArg)
+					}
 
 					if p.Match(TokenSymbol, ")") != nil {
 						// If there's a closing bracket after an expression, we will stop parsing the arguments
@@ -814,7 +1177,7 @@ filterLoop:
 		}
 
 		// Check sandbox filter restriction
-		if _, isBanned := p.template.set.bannedFilters[filter.name]; isBanned {
+		if p.template.set.isFilterBanned(filter.name) {
 			return nil, p.Error(fmt.Sprintf("Usage of filter '%s' is not allowed (sandbox restriction active).", filter.name), nil)
 		}
 